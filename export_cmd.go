@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/uptrace/bun"
+)
+
+// runExportCommand escribe todos los ratings en CSV o JSON, en stdout o en -o
+func runExportCommand(cfg *config.Config, db *bun.DB, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", service.ExportCSV, "csv o json")
+	out := fs.String("o", "", "archivo de salida; por defecto stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("error creando %s: %v", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := service.ExportStocks(context.Background(), db, w, *format)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ %d ratings exportados.", n)
+	return nil
+}
@@ -0,0 +1,419 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile es el archivo de configuración que se lee si existe y CONFIG_FILE no está definido
+const DefaultFile = "config.yaml"
+
+// Config es la configuración completa de la aplicación
+type Config struct {
+	Server    Server
+	Database  Database
+	Upstream  Upstream
+	Finnhub   Finnhub
+	Ingestion Ingestion
+	Scoring   Scoring
+
+	// values guarda el valor crudo de cada variable para poder loguearlo
+	values map[string]string
+}
+
+type Server struct {
+	Port        string
+	CORSOrigins []string
+	// ShutdownTimeout es cuánto se espera al apagar a que terminen las
+	// peticiones en curso y la sincronización en segundo plano
+	ShutdownTimeout time.Duration
+}
+
+// Motores de base de datos soportados
+const (
+	DriverCockroach = "cockroach"
+	DriverPostgres  = "postgres"
+	DriverSQLite    = "sqlite"
+)
+
+type Database struct {
+	Driver   string // cockroach, postgres o sqlite
+	Path     string // archivo de SQLite
+	URL      string // DSN completo; si está definido reemplaza a los campos de conexión
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Name     string
+
+	SSLMode         string // disable, allow, prefer, require, verify-ca o verify-full
+	SSLRootCert     string
+	SSLCert         string // certificado de cliente; requiere SSLKey
+	SSLKey          string
+	ApplicationName string
+
+	StatementTimeout time.Duration // 0 sin límite
+	ConnectTimeout   time.Duration // espera máxima del ping inicial
+	MaxOpenConns     int           // 0 sin límite
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration // 0 sin límite
+	ConnMaxIdleTime  time.Duration // 0 sin límite
+}
+
+// Upstream es la API externa de ratings
+type Upstream struct {
+	URL         string
+	AuthHeader  string
+	Timeout     time.Duration
+	MaxAttempts int
+}
+
+type Finnhub struct {
+	APIKey string
+	URL    string // plantilla con %s para el ticker y la API key
+}
+
+type Ingestion struct {
+	RefreshInterval time.Duration // 0 desactiva el refresco periódico
+	ArchiveDir      string        // vacío si el archivo de páginas está desactivado
+}
+
+type Scoring struct {
+	// HalfLife es la vida media del decaimiento de los ratings en los
+	// rankings: un rating de esa antigüedad pesa la mitad (0 sin decaimiento)
+	HalfLife time.Duration
+}
+
+// setting es una variable de configuración: su nombre en el entorno, su ruta
+// en el archivo YAML/TOML y su valor por defecto
+type setting struct {
+	env    string
+	path   string
+	def    string
+	secret bool
+}
+
+var settings = []setting{
+	{env: "PORT", path: "server.port", def: "8080"},
+	{env: "CORS_ORIGINS", path: "server.cors_origins", def: "http://localhost:5173"},
+	{env: "SHUTDOWN_TIMEOUT", path: "server.shutdown_timeout", def: "30s"},
+	{env: "DB_DRIVER", path: "database.driver", def: "cockroach"},
+	{env: "DB_PATH", path: "database.path", def: "stock-analyzer.db"},
+	{env: "DATABASE_URL", path: "database.url", secret: true},
+	{env: "DB_USER", path: "database.user"},
+	{env: "DB_PASSWORD", path: "database.password", secret: true},
+	{env: "DB_HOST", path: "database.host"},
+	{env: "DB_PORT", path: "database.port", def: "26257"},
+	{env: "DB_NAME", path: "database.name"},
+	{env: "DB_SSLMODE", path: "database.sslmode", def: "verify-full"},
+	{env: "DB_SSLROOTCERT", path: "database.sslrootcert"},
+	{env: "DB_SSLCERT", path: "database.sslcert"},
+	{env: "DB_SSLKEY", path: "database.sslkey"},
+	{env: "DB_APPLICATION_NAME", path: "database.application_name", def: "stock-analyzer"},
+	{env: "DB_STATEMENT_TIMEOUT", path: "database.statement_timeout", def: "0"},
+	{env: "DB_CONNECT_TIMEOUT", path: "database.connect_timeout", def: "10s"},
+	{env: "DB_MAX_OPEN_CONNS", path: "database.max_open_conns", def: "10"},
+	{env: "DB_MAX_IDLE_CONNS", path: "database.max_idle_conns", def: "5"},
+	{env: "DB_CONN_MAX_LIFETIME", path: "database.conn_max_lifetime", def: "30m"},
+	{env: "DB_CONN_MAX_IDLE_TIME", path: "database.conn_max_idle_time", def: "5m"},
+	{env: "API_URL", path: "upstream.url"},
+	{env: "AUTH_HEADER", path: "upstream.auth_header", secret: true},
+	{env: "UPSTREAM_TIMEOUT", path: "upstream.timeout", def: "30s"},
+	{env: "UPSTREAM_MAX_ATTEMPTS", path: "upstream.max_attempts", def: "5"},
+	{env: "FINNHUB_APIKEY", path: "finnhub.api_key", secret: true},
+	{env: "FINNHUB_URL", path: "finnhub.url"},
+	{env: "REFRESH_INTERVAL", path: "ingestion.refresh_interval", def: "15m"},
+	{env: "ARCHIVE_DIR", path: "ingestion.archive_dir", def: "archive"},
+	{env: "SCORING_HALF_LIFE", path: "scoring.half_life", def: "2160h"},
+}
+
+// Load lee la configuración con esta prioridad: variables de entorno, luego
+// .env (si existe), luego el archivo YAML/TOML de CONFIG_FILE o config.yaml
+// (si existe), y por último los valores por defecto. Valida todo de una vez y
+// devuelve un error con cada problema encontrado.
+func Load() (*Config, error) {
+	// godotenv no pisa las variables ya definidas en el entorno
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error leyendo .env: %v", err)
+	}
+
+	path, required := os.LookupEnv("CONFIG_FILE")
+	if !required {
+		path = DefaultFile
+	}
+	file, err := readFile(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(settings))
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok {
+			value, ok = file[s.path]
+		}
+		if !ok {
+			value = s.def
+		}
+		values[s.env] = strings.TrimSpace(value)
+	}
+	return parse(values)
+}
+
+// parse convierte los valores crudos en la configuración tipada y la valida
+func parse(values map[string]string) (*Config, error) {
+	var problems []string
+	required := func(key string) string {
+		if values[key] == "" {
+			problems = append(problems, key+" es requerido")
+		}
+		return values[key]
+	}
+	duration := func(key string) time.Duration {
+		d, err := time.ParseDuration(values[key])
+		if err != nil || d < 0 {
+			problems = append(problems, fmt.Sprintf("%s inválido %q: debe ser una duración como 15m", key, values[key]))
+		}
+		return d
+	}
+	positive := func(key string) int {
+		n, err := strconv.Atoi(values[key])
+		if err != nil || n < 1 {
+			problems = append(problems, fmt.Sprintf("%s inválido %q: debe ser un entero positivo", key, values[key]))
+		}
+		return n
+	}
+	nonNegative := func(key string) int {
+		n, err := strconv.Atoi(values[key])
+		if err != nil || n < 0 {
+			problems = append(problems, fmt.Sprintf("%s inválido %q: debe ser un entero mayor o igual a 0", key, values[key]))
+		}
+		return n
+	}
+	// Con DATABASE_URL o SQLite los campos de conexión sueltos son opcionales
+	dbField := func(key string) string {
+		if values["DATABASE_URL"] != "" || values["DB_DRIVER"] == DriverSQLite {
+			return values[key]
+		}
+		return required(key)
+	}
+
+	cfg := &Config{
+		Server: Server{
+			Port:            required("PORT"),
+			CORSOrigins:     splitList(values["CORS_ORIGINS"]),
+			ShutdownTimeout: duration("SHUTDOWN_TIMEOUT"),
+		},
+		Database: Database{
+			Driver:   values["DB_DRIVER"],
+			Path:     values["DB_PATH"],
+			URL:      values["DATABASE_URL"],
+			User:     dbField("DB_USER"),
+			Password: values["DB_PASSWORD"],
+			Host:     dbField("DB_HOST"),
+			Port:     dbField("DB_PORT"),
+			Name:     dbField("DB_NAME"),
+
+			SSLMode:         values["DB_SSLMODE"],
+			SSLRootCert:     values["DB_SSLROOTCERT"],
+			SSLCert:         values["DB_SSLCERT"],
+			SSLKey:          values["DB_SSLKEY"],
+			ApplicationName: values["DB_APPLICATION_NAME"],
+
+			StatementTimeout: duration("DB_STATEMENT_TIMEOUT"),
+			ConnectTimeout:   duration("DB_CONNECT_TIMEOUT"),
+			MaxOpenConns:     nonNegative("DB_MAX_OPEN_CONNS"),
+			MaxIdleConns:     nonNegative("DB_MAX_IDLE_CONNS"),
+			ConnMaxLifetime:  duration("DB_CONN_MAX_LIFETIME"),
+			ConnMaxIdleTime:  duration("DB_CONN_MAX_IDLE_TIME"),
+		},
+		Upstream: Upstream{
+			URL:         values["API_URL"],
+			AuthHeader:  values["AUTH_HEADER"],
+			Timeout:     duration("UPSTREAM_TIMEOUT"),
+			MaxAttempts: positive("UPSTREAM_MAX_ATTEMPTS"),
+		},
+		Finnhub: Finnhub{
+			APIKey: values["FINNHUB_APIKEY"],
+			URL:    values["FINNHUB_URL"],
+		},
+		Ingestion: Ingestion{
+			RefreshInterval: duration("REFRESH_INTERVAL"),
+			ArchiveDir:      values["ARCHIVE_DIR"],
+		},
+		Scoring: Scoring{
+			HalfLife: duration("SCORING_HALF_LIFE"),
+		},
+		values: values,
+	}
+
+	if _, err := strconv.Atoi(cfg.Server.Port); cfg.Server.Port != "" && err != nil {
+		problems = append(problems, fmt.Sprintf("PORT inválido %q", cfg.Server.Port))
+	}
+	problems = append(problems, cfg.Database.validate()...)
+	if u := cfg.Upstream.URL; u != "" {
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("API_URL inválido %q", u))
+		}
+	}
+	if cfg.Ingestion.ArchiveDir == "off" {
+		cfg.Ingestion.ArchiveDir = ""
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("configuración inválida:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return cfg, nil
+}
+
+// drivers son los motores de base de datos soportados
+var drivers = []string{DriverCockroach, DriverPostgres, DriverSQLite}
+
+// sslModes son los valores de sslmode que entiende el driver
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// validate revisa las opciones de conexión que no se pueden validar campo por campo
+func (d Database) validate() []string {
+	var problems []string
+	switch {
+	case !slices.Contains(drivers, d.Driver):
+		return []string{fmt.Sprintf("DB_DRIVER inválido %q: usa %s", d.Driver, strings.Join(drivers, ", "))}
+	case d.Driver == DriverSQLite:
+		if d.Path == "" {
+			problems = append(problems, "DB_PATH es requerido con DB_DRIVER=sqlite")
+		}
+		return problems
+	}
+
+	if d.URL != "" {
+		if u, err := url.Parse(d.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			problems = append(problems, "DATABASE_URL inválido: debe empezar con postgresql://")
+		}
+	}
+	if !slices.Contains(sslModes, d.SSLMode) {
+		problems = append(problems, fmt.Sprintf("DB_SSLMODE inválido %q: usa %s", d.SSLMode, strings.Join(sslModes, ", ")))
+	}
+	if (d.SSLCert == "") != (d.SSLKey == "") {
+		problems = append(problems, "DB_SSLCERT y DB_SSLKEY se deben indicar juntos")
+	}
+	if d.SSLCert != "" && d.SSLMode == "disable" {
+		problems = append(problems, "DB_SSLCERT requiere un DB_SSLMODE distinto de disable")
+	}
+	for key, path := range map[string]string{"DB_SSLROOTCERT": d.SSLRootCert, "DB_SSLCERT": d.SSLCert, "DB_SSLKEY": d.SSLKey} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: no se puede leer %s", key, path))
+		}
+	}
+	if d.ConnectTimeout == 0 {
+		problems = append(problems, "DB_CONNECT_TIMEOUT debe ser mayor que 0")
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// Validate comprueba que la API externa esté configurada; solo la necesitan
+// los comandos que sincronizan desde ella
+func (u Upstream) Validate() error {
+	var missing []string
+	if u.URL == "" {
+		missing = append(missing, "API_URL")
+	}
+	if u.AuthHeader == "" {
+		missing = append(missing, "AUTH_HEADER")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("falta configurar la API externa: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// String lista la configuración efectiva con los secretos ocultos, apta para logs
+func (c *Config) String() string {
+	parts := make([]string, 0, len(settings))
+	for _, s := range settings {
+		value := c.values[s.env]
+		if s.secret && value != "" {
+			value = "****"
+		}
+		parts = append(parts, s.env+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// readFile lee un archivo YAML o TOML y lo aplana a rutas como "database.host".
+// Si no es obligatorio y no existe, devuelve un mapa vacío.
+func readFile(path string, required bool) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+
+	var tree map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("formato de configuración no soportado %q: usa .yaml o .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+
+	flat := make(map[string]string)
+	flatten("", tree, flat)
+	return flat, nil
+}
+
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for key, value := range tree {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(path, v, out)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			out[path] = strings.Join(items, ",")
+		case nil:
+			out[path] = ""
+		default:
+			out[path] = fmt.Sprint(v)
+		}
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setEnv define las variables mínimas de la base de datos y aísla el test del
+// .env y del config.yaml del directorio de trabajo
+func setEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+		os.Unsetenv(s.env)
+	}
+	t.Setenv("CONFIG_FILE", "")
+	os.Unsetenv("CONFIG_FILE")
+
+	t.Setenv("DB_USER", "root")
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_NAME", "stocks")
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	setEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, "26257", cfg.Database.Port)
+	assert.Equal(t, 30*time.Second, cfg.Upstream.Timeout)
+	assert.Equal(t, 5, cfg.Upstream.MaxAttempts)
+	assert.Equal(t, 15*time.Minute, cfg.Ingestion.RefreshInterval)
+	assert.Equal(t, "archive", cfg.Ingestion.ArchiveDir)
+	assert.Equal(t, []string{"http://localhost:5173"}, cfg.Server.CORSOrigins)
+	assert.Equal(t, 30*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 90*24*time.Hour, cfg.Scoring.HalfLife)
+	assert.Error(t, cfg.Upstream.Validate())
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	setEnv(t)
+	t.Setenv("DB_HOST", "")
+	t.Setenv("REFRESH_INTERVAL", "quince")
+	t.Setenv("UPSTREAM_MAX_ATTEMPTS", "0")
+
+	_, err := Load()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "DB_HOST es requerido")
+		assert.Contains(t, err.Error(), "REFRESH_INTERVAL inválido")
+		assert.Contains(t, err.Error(), "UPSTREAM_MAX_ATTEMPTS inválido")
+	}
+}
+
+func TestLoad_FilesAndPrecedence(t *testing.T) {
+	setEnv(t)
+	os.Unsetenv("DB_HOST")
+
+	assert.NoError(t, os.WriteFile(".env", []byte("DB_PASSWORD=secreto\nREFRESH_INTERVAL=5m\n"), 0o644))
+	assert.NoError(t, os.WriteFile("config.yaml", []byte(`
+database:
+  host: db.local
+upstream:
+  url: https://api.example.com/list
+  auth_header: Bearer yaml
+ingestion:
+  refresh_interval: 1h
+  archive_dir: "off"
+server:
+  cors_origins: [http://a.test, http://b.test]
+`), 0o644))
+	t.Setenv("AUTH_HEADER", "Bearer env")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "db.local", cfg.Database.Host)
+	assert.Equal(t, "secreto", cfg.Database.Password)
+	assert.Equal(t, "Bearer env", cfg.Upstream.AuthHeader)        // el entorno gana al archivo
+	assert.Equal(t, 5*time.Minute, cfg.Ingestion.RefreshInterval) // .env gana al archivo
+	assert.Empty(t, cfg.Ingestion.ArchiveDir)
+	assert.Equal(t, []string{"http://a.test", "http://b.test"}, cfg.Server.CORSOrigins)
+	assert.NoError(t, cfg.Upstream.Validate())
+
+	// Los secretos no aparecen al loguear la configuración
+	logged := cfg.String()
+	assert.NotContains(t, logged, "secreto")
+	assert.NotContains(t, logged, "Bearer env")
+	assert.Contains(t, logged, "DB_PASSWORD=****")
+	assert.Contains(t, logged, "DB_HOST=db.local")
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	setEnv(t)
+	path := filepath.Join(t.TempDir(), "app.toml")
+	assert.NoError(t, os.WriteFile(path, []byte("[server]\nport = 9090\n"), 0o644))
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Server.Port)
+
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func TestLoad_DatabaseSettings(t *testing.T) {
+	setEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-full", cfg.Database.SSLMode)
+	assert.Equal(t, "stock-analyzer", cfg.Database.ApplicationName)
+	assert.Equal(t, time.Duration(0), cfg.Database.StatementTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Database.ConnectTimeout)
+	assert.Equal(t, 10, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+
+	// Con DATABASE_URL no hacen falta los campos sueltos
+	setEnv(t)
+	os.Unsetenv("DB_USER")
+	os.Unsetenv("DB_HOST")
+	os.Unsetenv("DB_NAME")
+	t.Setenv("DATABASE_URL", "postgresql://root:secreto@db:26257/stocks?sslmode=disable")
+	t.Setenv("DB_SSLMODE", "disable")
+	t.Setenv("DB_STATEMENT_TIMEOUT", "30s")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Database.StatementTimeout)
+	assert.NotContains(t, cfg.String(), "secreto")
+}
+
+func TestLoad_DatabaseSettingsInvalid(t *testing.T) {
+	setEnv(t)
+	t.Setenv("DB_SSLMODE", "siempre")
+	t.Setenv("DB_SSLCERT", "client.crt")
+	t.Setenv("DB_MAX_OPEN_CONNS", "-1")
+	t.Setenv("DATABASE_URL", "mysql://db/stocks")
+
+	_, err := Load()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "DB_SSLMODE inválido")
+		assert.Contains(t, err.Error(), "DB_SSLCERT y DB_SSLKEY se deben indicar juntos")
+		assert.Contains(t, err.Error(), "DB_SSLCERT: no se puede leer client.crt")
+		assert.Contains(t, err.Error(), "DB_MAX_OPEN_CONNS inválido")
+		assert.Contains(t, err.Error(), "DATABASE_URL inválido")
+	}
+}
+
+func TestLoad_SQLite(t *testing.T) {
+	setEnv(t)
+	os.Unsetenv("DB_USER")
+	os.Unsetenv("DB_HOST")
+	os.Unsetenv("DB_NAME")
+	t.Setenv("DB_DRIVER", "sqlite")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DriverSQLite, cfg.Database.Driver)
+	assert.Equal(t, "stock-analyzer.db", cfg.Database.Path)
+
+	t.Setenv("DB_DRIVER", "mysql")
+	_, err = Load()
+	assert.ErrorContains(t, err, "DB_DRIVER inválido")
+}
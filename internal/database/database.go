@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+var DB *bun.DB
+
+// Open conecta con el motor indicado en DB_DRIVER y hace un ping antes de
+// devolver la conexión, así un error de credenciales o de red se detecta al arrancar
+func Open(cfg config.Database) (*bun.DB, error) {
+	var (
+		sqldb   *sql.DB
+		dialect schema.Dialect
+		err     error
+	)
+	switch cfg.Driver {
+	case config.DriverCockroach, config.DriverPostgres:
+		sqldb, dialect, err = openPostgres(cfg)
+	case config.DriverSQLite:
+		sqldb, dialect, err = openSQLite(cfg)
+	default:
+		err = fmt.Errorf("motor de base de datos desconocido %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == config.DriverSQLite {
+		// SQLite admite un solo escritor a la vez; con una conexión las
+		// escrituras concurrentes esperan en vez de fallar con "database is locked"
+		sqldb.SetMaxOpenConns(1)
+	} else {
+		sqldb.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqldb.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqldb.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqldb.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	if err := sqldb.PingContext(ctx); err != nil {
+		sqldb.Close()
+		return nil, fmt.Errorf("no se pudo conectar a la base de datos %s: %v", describe(cfg), err)
+	}
+
+	DB = bun.NewDB(sqldb, dialect)
+	log.Printf("✅ Conectado a %s (%s)", cfg.Driver, describe(cfg))
+
+	return DB, nil
+}
+
+// describe identifica la base de datos para los logs sin exponer la contraseña
+func describe(cfg config.Database) string {
+	if cfg.Driver == config.DriverSQLite {
+		return cfg.Path
+	}
+	u, err := url.Parse(connString(cfg))
+	if err != nil {
+		return "(DSN inválido)"
+	}
+	return u.Redacted()
+}
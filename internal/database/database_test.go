@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpen_SQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(config.Database{
+		Driver:         config.DriverSQLite,
+		Path:           filepath.Join(t.TempDir(), "stocks.db"),
+		ConnectTimeout: time.Second,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	// Subir, bajar y volver a subir todo el esquema
+	assert.NoError(t, MigrateUp(ctx, db))
+	for {
+		status, err := MigrationStatus(ctx, db)
+		assert.NoError(t, err)
+		if len(status.Applied()) == 0 {
+			break
+		}
+		assert.NoError(t, MigrateDown(ctx, db))
+	}
+	assert.NoError(t, MigrateUp(ctx, db))
+
+	item := models.StockItem{Ticker: "AAPL", Company: "Apple Inc.", TargetTo: "$180", Time: time.Now().UTC()}
+	item.NormalizeTargets()
+	_, err = db.NewInsert().Model(&item).Exec(ctx)
+	assert.NoError(t, err)
+
+	var found []models.StockItem
+	err = db.NewSelect().Model(&found).Where(ILike(db, "company"), "apple%").Scan(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestOpen_FailsFast(t *testing.T) {
+	_, err := Open(config.Database{
+		Driver:         config.DriverPostgres,
+		User:           "root",
+		Password:       "secreto",
+		Host:           "127.0.0.1",
+		Port:           "1",
+		Name:           "stocks",
+		SSLMode:        "disable",
+		ConnectTimeout: time.Second,
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no se pudo conectar a la base de datos")
+		assert.NotContains(t, err.Error(), "secreto")
+	}
+}
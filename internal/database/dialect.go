@@ -0,0 +1,15 @@
+package database
+
+import (
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// ILike devuelve la condición "column ILIKE ?" en el dialecto de db. SQLite no
+// tiene ILIKE, pero su LIKE ya ignora mayúsculas en ASCII.
+func ILike(db bun.IDB, column string) string {
+	if db.Dialect().Name() == dialect.SQLite {
+		return column + " LIKE ?"
+	}
+	return column + " ILIKE ?"
+}
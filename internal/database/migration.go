@@ -2,45 +2,97 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
-	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/database/migrations"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
 )
 
-func Migrate(db *bun.DB) {
-	ctx := context.Background()
+// migrationLockWait es cuánto se espera a que otra instancia termine de migrar
+const migrationLockWait = 2 * time.Minute
 
-	_, err := db.NewCreateTable().
-		Model((*models.StockItem)(nil)).
-		IfNotExists().
-		Exec(ctx)
+func newMigrator(db *bun.DB) *migrate.Migrator {
+	return migrate.NewMigrator(db, migrations.Migrations)
+}
+
+// MigrateUp aplica las migraciones pendientes
+func MigrateUp(ctx context.Context, db *bun.DB) error {
+	return withMigrationLock(ctx, db, func(m *migrate.Migrator) error {
+		group, err := m.Migrate(ctx)
+		if err != nil {
+			return fmt.Errorf("error aplicando migraciones: %v", err)
+		}
+		if group.IsZero() {
+			log.Println("✅ Esquema al día, no hay migraciones pendientes.")
+			return nil
+		}
+		log.Printf("✅ Migrado a %s", group)
+		return nil
+	})
+}
+
+// MigrateDown revierte el último grupo de migraciones aplicado
+func MigrateDown(ctx context.Context, db *bun.DB) error {
+	return withMigrationLock(ctx, db, func(m *migrate.Migrator) error {
+		group, err := m.Rollback(ctx)
+		if err != nil {
+			return fmt.Errorf("error revirtiendo migraciones: %v", err)
+		}
+		if group.IsZero() {
+			log.Println("ℹ️  No hay migraciones para revertir.")
+			return nil
+		}
+		log.Printf("↩️  Revertido %s", group)
+		return nil
+	})
+}
 
-	if err != nil {
-		log.Fatalf("❌ Error creando tabla stock_items: %v", err)
-	} else {
-		log.Println("✅ Tabla stock_items creada o ya existía.")
+// MigrationStatus devuelve todas las migraciones con su estado
+func MigrationStatus(ctx context.Context, db *bun.DB) (migrate.MigrationSlice, error) {
+	m := newMigrator(db)
+	if err := m.Init(ctx); err != nil {
+		return nil, fmt.Errorf("error inicializando tablas de migraciones: %v", err)
 	}
+	return m.MigrationsWithStatus(ctx)
 }
 
-func TableExists(db *bun.DB, tableName string) bool {
-	ctx := context.Background()
-
-	var exists bool
-	query := `
-		SELECT EXISTS (
-			SELECT 1
-			FROM information_schema.tables
-			WHERE table_schema = 'public'
-			AND table_name = ?
-		)
-	`
-
-	err := db.QueryRowContext(ctx, query, tableName).Scan(&exists)
-	if err != nil {
-		log.Printf("⚠️  Error verificando existencia de tabla %s: %v", tableName, err)
-		return false
+// UnlockMigrations libera el lock si una instancia murió mientras migraba
+func UnlockMigrations(ctx context.Context, db *bun.DB) error {
+	return newMigrator(db).Unlock(ctx)
+}
+
+// withMigrationLock toma el lock de migraciones, esperando si otra instancia
+// lo tiene, y ejecuta fn mientras lo mantiene
+func withMigrationLock(ctx context.Context, db *bun.DB, fn func(m *migrate.Migrator) error) error {
+	m := newMigrator(db)
+	if err := m.Init(ctx); err != nil {
+		return fmt.Errorf("error inicializando tablas de migraciones: %v", err)
+	}
+
+	deadline := time.Now().Add(migrationLockWait)
+	for {
+		err := m.Lock(ctx)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no se pudo obtener el lock de migraciones tras %s; si ninguna instancia está migrando, libéralo con 'migrate unlock': %v", migrationLockWait, err)
+		}
+		log.Println("⏳ Otra instancia está migrando, esperando el lock...")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
 	}
+	defer func() {
+		if err := m.Unlock(context.WithoutCancel(ctx)); err != nil {
+			log.Printf("⚠️  Error liberando el lock de migraciones: %v", err)
+		}
+	}()
 
-	return exists
+	return fn(m)
 }
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// stockItemV1 es la tabla stock_items tal como se creó originalmente
+type stockItemV1 struct {
+	bun.BaseModel `bun:"table:stock_items"`
+
+	ID         int64     `bun:",pk,autoincrement"`
+	Ticker     string    `bun:"ticker,notnull"`
+	TargetFrom string    `bun:"target_from,notnull"`
+	TargetTo   string    `bun:"target_to,notnull"`
+	Company    string    `bun:"company,notnull"`
+	Action     string    `bun:"action,notnull"`
+	Brokerage  string    `bun:"brokerage,notnull"`
+	RatingFrom string    `bun:"rating_from,notnull"`
+	RatingTo   string    `bun:"rating_to,notnull"`
+	Time       time.Time `bun:"time,notnull,type:timestamptz"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model((*stockItemV1)(nil)).
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().
+			Model((*stockItemV1)(nil)).
+			IfExists().
+			Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+const naturalKeyColumns = "ticker, brokerage, time, action, rating_from, rating_to, target_from, target_to"
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Conservar solo el primer rating de cada clave natural
+		_, err := db.NewDelete().
+			Model((*stockItemV1)(nil)).
+			Where("id NOT IN (?)", db.NewSelect().
+				Model((*stockItemV1)(nil)).
+				ColumnExpr("MIN(id)").
+				GroupExpr(naturalKeyColumns)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx,
+			"CREATE UNIQUE INDEX IF NOT EXISTS stock_items_natural_key ON stock_items ("+naturalKeyColumns+")")
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, "DROP INDEX IF EXISTS stock_items_natural_key")
+		return err
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type ingestionCheckpointV1 struct {
+	bun.BaseModel `bun:"table:ingestion_checkpoints"`
+
+	RunID       string    `bun:"run_id,pk"`
+	Mode        string    `bun:"mode,notnull"`
+	Since       time.Time `bun:"since,nullzero,type:timestamptz"`
+	NextPage    string    `bun:"next_page,notnull"`
+	Page        int       `bun:"page,notnull"`
+	RowsWritten int       `bun:"rows_written,notnull"`
+	Completed   bool      `bun:"completed,notnull"`
+	UpdatedAt   time.Time `bun:"updated_at,notnull,type:timestamptz"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model((*ingestionCheckpointV1)(nil)).
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().
+			Model((*ingestionCheckpointV1)(nil)).
+			IfExists().
+			Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type ingestionRunV1 struct {
+	bun.BaseModel `bun:"table:ingestion_runs"`
+
+	ID         string    `bun:"id,pk"`
+	Trigger    string    `bun:"trigger,notnull"`
+	Mode       string    `bun:"mode,notnull"`
+	Status     string    `bun:"status,notnull"`
+	StartedAt  time.Time `bun:"started_at,notnull,type:timestamptz"`
+	FinishedAt time.Time `bun:"finished_at,nullzero,type:timestamptz"`
+	Pages      int       `bun:"pages,notnull"`
+	Inserted   int       `bun:"inserted,notnull"`
+	Updated    int       `bun:"updated,notnull"`
+	Skipped    int       `bun:"skipped,notnull"`
+	Error      string    `bun:"error,notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model((*ingestionRunV1)(nil)).
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().
+			Model((*ingestionRunV1)(nil)).
+			IfExists().
+			Exec(ctx)
+		return err
+	})
+}
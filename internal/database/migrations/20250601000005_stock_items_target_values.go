@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// stockItemTargetsV5 son las columnas de stock_items que usa el backfill de
+// esta migración, congeladas para que cambios futuros en models.StockItem no
+// alteren lo que hace
+type stockItemTargetsV5 struct {
+	bun.BaseModel `bun:"table:stock_items"`
+
+	ID              int64    `bun:",pk,autoincrement"`
+	TargetFrom      string   `bun:"target_from"`
+	TargetTo        string   `bun:"target_to"`
+	TargetFromValue *float64 `bun:"target_from_value"`
+	TargetToValue   *float64 `bun:"target_to_value"`
+	Currency        string   `bun:"currency"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		for _, col := range [][2]string{
+			{"target_from_value", "NUMERIC"},
+			{"target_to_value", "NUMERIC"},
+			{"currency", "VARCHAR"},
+		} {
+			if err := addColumn(ctx, db, "stock_items", col[0], col[1]); err != nil {
+				return err
+			}
+		}
+		return backfillTargetValues(ctx, db)
+	}, func(ctx context.Context, db *bun.DB) error {
+		for _, col := range []string{"currency", "target_to_value", "target_from_value"} {
+			if err := dropColumn(ctx, db, "stock_items", col); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillTargetValues calcula los precios numéricos de las filas existentes por lotes
+func backfillTargetValues(ctx context.Context, db *bun.DB) error {
+	for {
+		var batch []stockItemTargetsV5
+		err := db.NewSelect().
+			Model(&batch).
+			Column("id", "target_from", "target_to").
+			Where("currency IS NULL").
+			Limit(500).
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for i := range batch {
+			// Las filas sin precio válido quedan con currency vacío para no volver a leerlas
+			row := &batch[i]
+			if value, currency, ok := parsePriceV5(row.TargetFrom); ok {
+				row.TargetFromValue, row.Currency = &value, currency
+			}
+			if value, currency, ok := parsePriceV5(row.TargetTo); ok {
+				row.TargetToValue, row.Currency = &value, currency
+			}
+
+			_, err := db.NewUpdate().
+				Model(row).
+				Column("target_from_value", "target_to_value", "currency").
+				WherePK().
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// currencySymbolsV5 son los símbolos de moneda que reconocía el backfill
+var currencySymbolsV5 = [][2]string{
+	{"$", "USD"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+}
+
+// parsePriceV5 es una copia del parser de precios de models tal como estaba al
+// escribir esta migración, para que cambios posteriores no alteren el backfill
+func parsePriceV5(s string) (float64, string, bool) {
+	raw := strings.TrimSpace(s)
+	currency := "USD"
+
+	for _, symbol := range currencySymbolsV5 {
+		if strings.HasPrefix(raw, symbol[0]) {
+			raw = strings.TrimPrefix(raw, symbol[0])
+			currency = symbol[1]
+			break
+		}
+	}
+
+	raw = strings.ReplaceAll(raw, ",", "")
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return value, currency, true
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type stockItemRejectedV1 struct {
+	bun.BaseModel `bun:"table:stock_items_rejected"`
+
+	ID        int64     `bun:",pk,autoincrement"`
+	RunID     string    `bun:"run_id,notnull"`
+	Page      int       `bun:"page,notnull"`
+	Reason    string    `bun:"reason,notnull"`
+	Raw       string    `bun:"raw,notnull,type:jsonb"`
+	CreatedAt time.Time `bun:"created_at,notnull,type:timestamptz"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model((*stockItemRejectedV1)(nil)).
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*stockItemRejectedV1)(nil)).
+			Index("stock_items_rejected_run_id_idx").
+			Column("run_id").
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return addColumn(ctx, db, "ingestion_runs", "rejected", "INTEGER NOT NULL DEFAULT 0")
+	}, func(ctx context.Context, db *bun.DB) error {
+		if err := dropColumn(ctx, db, "ingestion_runs", "rejected"); err != nil {
+			return err
+		}
+		_, err := db.NewDropTable().
+			Model((*stockItemRejectedV1)(nil)).
+			IfExists().
+			Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type scoringProfileV1 struct {
+	bun.BaseModel `bun:"table:scoring_profiles"`
+
+	Name      string    `bun:"name,pk"`
+	Version   int       `bun:"version,notnull"`
+	Weights   string    `bun:"weights,notnull,type:jsonb"`
+	UpdatedBy string    `bun:"updated_by,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull,type:timestamptz"`
+}
+
+type scoringProfileVersionV1 struct {
+	bun.BaseModel `bun:"table:scoring_profile_versions"`
+
+	ID        int64     `bun:",pk,autoincrement"`
+	Name      string    `bun:"name,notnull,unique:scoring_profile_versions_name_version"`
+	Version   int       `bun:"version,notnull,unique:scoring_profile_versions_name_version"`
+	Weights   string    `bun:"weights,notnull,type:jsonb"`
+	Deleted   bool      `bun:"deleted,notnull"`
+	ChangedBy string    `bun:"changed_by,notnull"`
+	ChangedAt time.Time `bun:"changed_at,notnull,type:timestamptz"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		for _, model := range []any{(*scoringProfileV1)(nil), (*scoringProfileVersionV1)(nil)} {
+			if _, err := db.NewCreateTable().Model(model).IfNotExists().Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		for _, model := range []any{(*scoringProfileVersionV1)(nil), (*scoringProfileV1)(nil)} {
+			if _, err := db.NewDropTable().Model(model).IfExists().Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// addColumn agrega una columna si no existe. SQLite no soporta
+// ADD COLUMN IF NOT EXISTS, así que ahí se consulta antes.
+func addColumn(ctx context.Context, db *bun.DB, table, column, definition string) error {
+	if db.Dialect().Name() != dialect.SQLite {
+		_, err := db.ExecContext(ctx, "ALTER TABLE ? ADD COLUMN IF NOT EXISTS ? "+definition,
+			bun.Ident(table), bun.Ident(column))
+		return err
+	}
+
+	exists, err := columnExists(ctx, db, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "ALTER TABLE ? ADD COLUMN ? "+definition, bun.Ident(table), bun.Ident(column))
+	return err
+}
+
+// dropColumn elimina una columna si existe
+func dropColumn(ctx context.Context, db *bun.DB, table, column string) error {
+	if db.Dialect().Name() != dialect.SQLite {
+		_, err := db.ExecContext(ctx, "ALTER TABLE ? DROP COLUMN IF EXISTS ?", bun.Ident(table), bun.Ident(column))
+		return err
+	}
+
+	exists, err := columnExists(ctx, db, table, column)
+	if err != nil || !exists {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "ALTER TABLE ? DROP COLUMN ?", bun.Ident(table), bun.Ident(column))
+	return err
+}
+
+// columnExists consulta las columnas de una tabla en SQLite
+func columnExists(ctx context.Context, db *bun.DB, table, column string) (bool, error) {
+	var n int
+	err := db.NewSelect().
+		TableExpr("pragma_table_info(?)", table).
+		ColumnExpr("COUNT(*)").
+		Where("name = ?", column).
+		Scan(ctx, &n)
+	return n > 0, err
+}
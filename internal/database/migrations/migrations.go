@@ -0,0 +1,8 @@
+// Package migrations contiene las migraciones versionadas del esquema.
+// Cada archivo se llama <versión>_<descripción>.go y registra su up y down.
+package migrations
+
+import "github.com/uptrace/bun/migrate"
+
+// Migrations es el conjunto ordenado de migraciones de la aplicación
+var Migrations = migrate.NewMigrations()
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"github.com/uptrace/bun/schema"
+)
+
+// openPostgres abre el pool con pgdriver, que sirve tanto para CockroachDB
+// como para PostgreSQL
+func openPostgres(cfg config.Database) (*sql.DB, schema.Dialect, error) {
+	connector, err := newConnector(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sql.OpenDB(connector), pgdialect.New(), nil
+}
+
+// newConnector arma el conector a partir del DSN y agrega lo que el DSN no
+// soporta: certificado de cliente y statement_timeout
+func newConnector(cfg config.Database) (connector *pgdriver.Connector, err error) {
+	// pgdriver entra en pánico si el DSN es inválido o el certificado raíz no se puede leer
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("configuración de base de datos inválida: %v", r)
+		}
+	}()
+	connector = pgdriver.NewConnector(pgdriver.WithDSN(connString(cfg)))
+	conf := connector.Config()
+
+	if cfg.SSLCert != "" {
+		if conf.TLSConfig == nil {
+			return nil, fmt.Errorf("DB_SSLCERT requiere TLS, pero la conexión no usa TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo el certificado de cliente: %v", err)
+		}
+		conf.TLSConfig.Certificates = append(conf.TLSConfig.Certificates, cert)
+	}
+
+	if cfg.StatementTimeout > 0 {
+		if conf.ConnParams == nil {
+			conf.ConnParams = make(map[string]any)
+		}
+		conf.ConnParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	return connector, nil
+}
+
+// connString devuelve DATABASE_URL si está definido o arma el DSN con los campos sueltos
+func connString(cfg config.Database) string {
+	if cfg.URL != "" {
+		return cfg.URL
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	if cfg.ApplicationName != "" {
+		query.Set("application_name", cfg.ApplicationName)
+	}
+
+	u := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	if cfg.Password == "" {
+		u.User = url.User(cfg.User)
+	}
+	return u.String()
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+	"github.com/uptrace/bun/schema"
+)
+
+// openSQLite abre el archivo de DB_PATH, pensado para desarrollo local sin clúster.
+// sqliteshim usa el driver con cgo si está disponible y si no el de Go puro.
+func openSQLite(cfg config.Database) (*sql.DB, schema.Dialect, error) {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file:"+cfg.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error abriendo SQLite %s: %v", cfg.Path, err)
+	}
+	return sqldb, sqlitedialect.New(), nil
+}
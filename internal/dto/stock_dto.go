@@ -1,6 +1,12 @@
 package dto
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+)
 
 type StockItem struct {
 	Ticker     string `json:"ticker"`
@@ -14,7 +20,58 @@ type StockItem struct {
 	Time       string `json:"time"`
 }
 
-func (s StockItem) ParseTime() time.Time {
-	t, _ := time.Parse(time.RFC3339Nano, s.Time)
-	return t
+// timeLayouts son los formatos aceptados para Time: el RFC 3339 de la API y,
+// para hojas importadas, fecha y hora sin zona (UTC) o solo fecha
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+func (s StockItem) ParseTime() (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s.Time)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time inválido %q", s.Time)
+}
+
+// ToModel valida el item y lo convierte en el modelo persistido, con los
+// textos sin espacios alrededor y el ticker en mayúsculas. El loader y la
+// importación pasan por aquí, así que el mismo rating da la misma clave
+// natural venga de donde venga. El error describe por qué el item no es válido.
+func (s StockItem) ToModel() (models.StockItem, error) {
+	if err := s.Validate(); err != nil {
+		return models.StockItem{}, err
+	}
+	t, err := s.ParseTime()
+	if err != nil {
+		return models.StockItem{}, err
+	}
+
+	m := models.StockItem{
+		Ticker:     strings.ToUpper(strings.TrimSpace(s.Ticker)),
+		TargetFrom: strings.TrimSpace(s.TargetFrom),
+		TargetTo:   strings.TrimSpace(s.TargetTo),
+		Company:    strings.TrimSpace(s.Company),
+		Action:     strings.TrimSpace(s.Action),
+		Brokerage:  strings.TrimSpace(s.Brokerage),
+		RatingFrom: strings.TrimSpace(s.RatingFrom),
+		RatingTo:   strings.TrimSpace(s.RatingTo),
+		Time:       t.Truncate(models.TimePrecision),
+	}
+	m.NormalizeTargets()
+	return m, nil
+}
+
+// FromModel convierte un item almacenado al formato de la API
+func FromModel(m models.StockItem) StockItem {
+	return StockItem{
+		Ticker:     m.Ticker,
+		TargetFrom: m.TargetFrom,
+		TargetTo:   m.TargetTo,
+		Company:    m.Company,
+		Action:     m.Action,
+		Brokerage:  m.Brokerage,
+		RatingFrom: m.RatingFrom,
+		RatingTo:   m.RatingTo,
+		Time:       m.Time.UTC().Format(time.RFC3339Nano),
+	}
 }
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+)
+
+// Clases de rating para el consenso
+const (
+	RatingBuy  = "buy"
+	RatingHold = "hold"
+	RatingSell = "sell"
+)
+
+// knownRatings es el vocabulario de ratings que publican las corredoras, con su clase
+var knownRatings = map[string]string{
+	"buy": RatingBuy, "strong-buy": RatingBuy, "strong buy": RatingBuy, "speculative buy": RatingBuy,
+	"moderate buy": RatingBuy, "outperform": RatingBuy, "market outperform": RatingBuy,
+	"sector outperform": RatingBuy, "overweight": RatingBuy, "positive": RatingBuy,
+	"accumulate": RatingBuy, "top pick": RatingBuy, "add": RatingBuy,
+	"hold": RatingHold, "neutral": RatingHold, "market perform": RatingHold, "sector perform": RatingHold,
+	"peer perform": RatingHold, "equal weight": RatingHold, "equal-weight": RatingHold,
+	"sector weight": RatingHold, "in-line": RatingHold, "inline": RatingHold, "cautious": RatingHold,
+	"sell": RatingSell, "strong-sell": RatingSell, "strong sell": RatingSell, "underperform": RatingSell,
+	"market underperform": RatingSell, "sector underperform": RatingSell,
+	"underweight": RatingSell, "negative": RatingSell, "reduce": RatingSell,
+}
+
+// knownActionVerbs son las palabras que identifican una acción válida,
+// como "target raised by" o "upgraded by"
+var knownActionVerbs = []string{
+	"upgraded", "downgraded", "raised", "lowered", "initiated",
+	"reiterated", "maintained", "set", "resumed",
+}
+
+// Validate revisa campos requeridos, time, precios objetivo y vocabulario
+func (s StockItem) Validate() error {
+	var problems []string
+
+	for _, f := range []struct{ name, value string }{
+		{"ticker", s.Ticker},
+		{"brokerage", s.Brokerage},
+		{"action", s.Action},
+		{"rating_to", s.RatingTo},
+		{"time", s.Time},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			problems = append(problems, fmt.Sprintf("falta %s", f.name))
+		}
+	}
+
+	if s.Time != "" {
+		if _, err := s.ParseTime(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if _, _, err := models.ParsePrice(s.TargetFrom); err != nil {
+		problems = append(problems, "target_from: "+err.Error())
+	}
+	if _, _, err := models.ParsePrice(s.TargetTo); err != nil {
+		problems = append(problems, "target_to: "+err.Error())
+	}
+
+	if s.RatingTo != "" && !IsKnownRating(s.RatingTo) {
+		problems = append(problems, fmt.Sprintf("rating_to desconocido %q", s.RatingTo))
+	}
+	if s.RatingFrom != "" && !IsKnownRating(s.RatingFrom) {
+		problems = append(problems, fmt.Sprintf("rating_from desconocido %q", s.RatingFrom))
+	}
+	if s.Action != "" && !IsKnownAction(s.Action) {
+		problems = append(problems, fmt.Sprintf("action desconocida %q", s.Action))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func IsKnownRating(rating string) bool {
+	return RatingClass(rating) != ""
+}
+
+// RatingClass devuelve buy, hold o sell según el rating, o "" si no es conocido
+func RatingClass(rating string) string {
+	return knownRatings[strings.ToLower(strings.TrimSpace(rating))]
+}
+
+func IsKnownAction(action string) bool {
+	lower := strings.ToLower(action)
+	for _, verb := range knownActionVerbs {
+		if strings.Contains(lower, verb) {
+			return true
+		}
+	}
+	return false
+}
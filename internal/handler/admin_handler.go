@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+func GetRefreshStatus(job *service.RefreshJob) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, job.Status())
+	}
+}
+
+func GetIngestionRuns(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 {
+			limit = 20
+		}
+
+		runs, err := service.ListIngestionRuns(c, db, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las ejecuciones"})
+			return
+		}
+
+		c.JSON(http.StatusOK, runs)
+	}
+}
+
+func GetIngestionRun(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		run, checkpoint, err := service.GetIngestionRun(c, db, c.Param("id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ejecución no encontrada"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la ejecución"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"run":        run,
+			"checkpoint": checkpoint,
+		})
+	}
+}
+
+func GetIngestionRejects(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 {
+			limit = 50
+		}
+
+		rejected, total, err := service.ListRejectedItems(c, db, c.Param("id"), limit, (page-1)*limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los items rechazados"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":  rejected,
+			"total": total,
+		})
+	}
+}
+
+func TriggerIngestion(job *service.RefreshJob) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode := service.SyncIncremental
+		switch c.DefaultQuery("mode", "incremental") {
+		case "incremental":
+		case "full":
+			mode = service.SyncFull
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'mode' debe ser 'full' o 'incremental'"})
+			return
+		}
+
+		runID, err := job.Trigger(mode)
+		if errors.Is(err, service.ErrNoRefreshSource) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No hay una API externa configurada para sincronizar"})
+			return
+		}
+		if errors.Is(err, service.ErrRefreshStopped) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "El servidor se está deteniendo"})
+			return
+		}
+		if errors.Is(err, service.ErrRefreshInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya hay una sincronización en curso"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar la sincronización"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"id":   runID,
+			"mode": mode.String(),
+		})
+	}
+}
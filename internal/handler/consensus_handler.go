@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+// GetStocksConsensus devuelve el consenso de las corredoras por ticker,
+// ordenado por score. Acepta los mismos parámetros de scoring que /top más
+// limit, min_brokerages, window y max_age.
+func GetStocksConsensus(db *bun.DB, cfg config.Scoring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params, ok := parseTopParams(c, db, cfg)
+		if !ok {
+			return
+		}
+		limit, ok := intQuery(c, "limit", 20, 1)
+		if !ok {
+			return
+		}
+		minBrokerages, ok := intQuery(c, "min_brokerages", 2, 1)
+		if !ok {
+			return
+		}
+		opts, ok := consensusOptions(c, params)
+		if !ok {
+			return
+		}
+
+		var stocks []models.StockItem
+		if err := params.filter(db.NewSelect().Model(&stocks)).Scan(c); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error cargando los datos"})
+			return
+		}
+
+		result := make([]service.Consensus, 0, limit)
+		for _, cons := range service.BuildConsensus(stocks, opts) {
+			if len(result) == limit {
+				break
+			}
+			if cons.Brokerages >= minBrokerages {
+				result = append(result, cons)
+			}
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// GetTickerConsensus devuelve el consenso de las corredoras sobre un ticker
+func GetTickerConsensus(db *bun.DB, cfg config.Scoring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticker := strings.ToUpper(strings.TrimSpace(c.Param("ticker")))
+		params, ok := parseTopParams(c, db, cfg)
+		if !ok {
+			return
+		}
+		opts, ok := consensusOptions(c, params)
+		if !ok {
+			return
+		}
+
+		var stocks []models.StockItem
+		err := params.filter(db.NewSelect().Model(&stocks)).
+			Where("ticker = ?", ticker).
+			Scan(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error cargando los datos"})
+			return
+		}
+		if len(stocks) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No hay ratings para el ticker " + ticker})
+			return
+		}
+
+		c.JSON(http.StatusOK, service.BuildConsensus(stocks, opts)[0])
+	}
+}
+
+// consensusOptions arma las opciones del consenso con el scorer de params, la
+// ventana del parámetro window y la antigüedad máxima de max_age. Si alguno es
+// inválido responde 400 y devuelve false.
+func consensusOptions(c *gin.Context, params topParams) (service.ConsensusOptions, bool) {
+	opts := service.ConsensusOptions{
+		Scorer: params.scorer,
+		AsOf:   params.asOf,
+		Window: service.DefaultConsensusWindow,
+		MaxAge: service.DefaultConsensusMaxAge,
+	}
+	if opts.AsOf.IsZero() {
+		opts.AsOf = time.Now()
+	}
+	if raw, present := c.GetQuery("window"); present {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'window' debe ser una duración positiva como 720h"})
+			return opts, false
+		}
+		opts.Window = d
+	}
+	if raw, present := c.GetQuery("max_age"); present {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'max_age' debe ser una duración como 4380h (0 sin límite)"})
+			return opts, false
+		}
+		opts.MaxAge = d
+	}
+	return opts, true
+}
+
+// intQuery lee un parámetro entero opcional con valor por defecto y mínimo.
+// Si es inválido responde 400 y devuelve false.
+func intQuery(c *gin.Context, key string, def, minValue int) (int, bool) {
+	raw, present := c.GetQuery(key)
+	if !present {
+		return def, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < minValue {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro '" + key + "' debe ser un entero mayor o igual que " + strconv.Itoa(minValue)})
+		return 0, false
+	}
+	return value, true
+}
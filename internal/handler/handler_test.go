@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
 	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/uptrace/bun"
@@ -21,11 +24,14 @@ func setupTestDB(t *testing.T) *bun.DB {
 	sqliteDB, err := sql.Open(sqliteshim.ShimName, ":memory:")
 	assert.NoError(t, err)
 
+	sqliteDB.SetMaxOpenConns(1)
+
 	db := bun.NewDB(sqliteDB, sqlitedialect.New())
 	db.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
 
-	// Crear tabla
-	err = db.ResetModel(contextBackground(), (*models.StockItem)(nil))
+	// Crear tablas
+	err = db.ResetModel(contextBackground(), (*models.StockItem)(nil),
+		(*models.ScoringProfile)(nil), (*models.ScoringProfileVersion)(nil))
 	assert.NoError(t, err)
 
 	// Insertar datos de prueba
@@ -103,7 +109,7 @@ func TestGetFilteredStocks(t *testing.T) {
 func TestGetTopInvestmentStocks(t *testing.T) {
 	db := setupTestDB(t)
 	router := gin.Default()
-	router.GET("/top", GetTopInvestmentStocks(db))
+	router.GET("/top", GetTopInvestmentStocks(db, config.Scoring{}))
 
 	resp := performRequest(router, "GET", "/top")
 	assert.Equal(t, 200, resp.Code)
@@ -117,7 +123,7 @@ func TestGetTopInvestmentStocks(t *testing.T) {
 func TestGetTopStocksByBrokerage(t *testing.T) {
 	db := setupTestDB(t)
 	router := gin.Default()
-	router.GET("/brokerage", GetTopStocksByBrokerage(db))
+	router.GET("/brokerage", GetTopStocksByBrokerage(db, config.Scoring{}))
 
 	resp := performRequest(router, "GET", "/brokerage?brokerage=goldman")
 	assert.Equal(t, 200, resp.Code)
@@ -163,16 +169,18 @@ func TestGetDistinctRatings(t *testing.T) {
 func TestGetCompanyInfoFromFinnhub(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	// Simular variables de entorno
-	os.Setenv("FINNHUB_APIKEY", "dummykey")
-	os.Setenv("FINNHUB_URL", "https://finnhub.io/api/v1/stock/profile2?symbol=%s&token=%s")
+	// Simular la configuración
+	cfg := config.Finnhub{
+		APIKey: "dummykey",
+		URL:    "https://finnhub.io/api/v1/stock/profile2?symbol=%s&token=%s",
+	}
 
 	req, _ := http.NewRequest(http.MethodGet, "/?ticker=AAPL", nil)
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	GetCompanyInfoFromFinnhub()(c)
+	GetCompanyInfoFromFinnhub(cfg)(c)
 
 	// Como estamos usando una API key dummy, esperamos un error 401
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -190,7 +198,7 @@ func TestGetCompanyInfoFromFinnhub_MissingTicker(t *testing.T) {
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
 	c.Request = req
 
-	GetCompanyInfoFromFinnhub()(c)
+	GetCompanyInfoFromFinnhub(config.Finnhub{})(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
@@ -200,3 +208,269 @@ func TestParseDollar(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1234.56, val)
 }
+
+func TestGetFilteredStocks_TargetRange(t *testing.T) {
+	db := setupTestDB(t)
+	router := gin.Default()
+	router.GET("/filtered", GetFilteredStocks(db))
+
+	resp := performRequest(router, "GET", "/filtered?target_min=$150&target_max=1,000")
+	assert.Equal(t, 200, resp.Code)
+
+	var body map[string]interface{}
+	err := json.Unmarshal(resp.Body.Bytes(), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), body["total"])
+
+	resp = performRequest(router, "GET", "/filtered?target_min=abc")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestGetFilteredStocks_Company(t *testing.T) {
+	db := setupTestDB(t)
+	router := gin.Default()
+	router.GET("/filtered", GetFilteredStocks(db))
+
+	// El prefijo no distingue mayúsculas en ningún motor
+	resp := performRequest(router, "GET", "/filtered?company=apple")
+	assert.Equal(t, 200, resp.Code)
+
+	var body map[string]interface{}
+	err := json.Unmarshal(resp.Body.Bytes(), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), body["total"])
+}
+
+func TestGetTopInvestmentStocks_Strategy(t *testing.T) {
+	db := setupTestDB(t)
+	router := gin.Default()
+	router.GET("/top", GetTopInvestmentStocks(db, config.Scoring{}))
+	router.GET("/brokerage", GetTopStocksByBrokerage(db, config.Scoring{}))
+
+	resp := performRequest(router, "GET", "/top?strategy=upside-only")
+	assert.Equal(t, 200, resp.Code)
+	var body []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.True(t, len(body) > 0)
+
+	resp = performRequest(router, "GET", "/top?strategy=magia")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "estrategia de puntuación desconocida")
+
+	resp = performRequest(router, "GET", "/brokerage?brokerage=goldman&strategy=magia")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestGetTopInvestmentStocks_Explain(t *testing.T) {
+	db := setupTestDB(t)
+	router := gin.Default()
+	router.GET("/top", GetTopInvestmentStocks(db, config.Scoring{}))
+
+	resp := performRequest(router, "GET", "/top?explain=true")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body []struct {
+		Ticker      string
+		Score       float64 `json:"score"`
+		Explanation []struct {
+			Name  string  `json:"name"`
+			Value float64 `json:"value"`
+			Rule  string  `json:"rule"`
+		} `json:"explanation"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	if assert.NotEmpty(t, body) {
+		assert.Equal(t, "AAPL", body[0].Ticker)
+		var sum float64
+		for _, c := range body[0].Explanation {
+			sum += c.Value
+			assert.NotEmpty(t, c.Rule)
+		}
+		assert.InDelta(t, body[0].Score, sum, 0.001)
+	}
+
+	// Sin explain no se incluye el desglose
+	resp = performRequest(router, "GET", "/top")
+	assert.NotContains(t, resp.Body.String(), "explanation")
+
+	resp = performRequest(router, "GET", "/top?explain=quizas")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestGetTopInvestmentStocks_DecayAndAsOf(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now().UTC()
+	recent := models.StockItem{Ticker: "NEW", TargetFrom: "$100", TargetTo: "$110", Time: now.AddDate(0, 0, -1)}
+	old := models.StockItem{Ticker: "OLD", TargetFrom: "$100", TargetTo: "$190", Time: now.AddDate(-1, 0, 0)}
+	_, err := db.NewTruncateTable().Model((*models.StockItem)(nil)).Exec(contextBackground())
+	assert.NoError(t, err)
+	_, err = db.NewInsert().Model(&[]models.StockItem{recent, old}).Exec(contextBackground())
+	assert.NoError(t, err)
+
+	router := gin.Default()
+	router.GET("/top", GetTopInvestmentStocks(db, config.Scoring{HalfLife: 30 * 24 * time.Hour}))
+	tickers := func(path string) []string {
+		resp := performRequest(router, "GET", path)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var body []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		var out []string
+		for _, e := range body {
+			out = append(out, e["Ticker"].(string))
+		}
+		return out
+	}
+
+	// Con decaimiento el rating reciente gana aunque suba menos
+	assert.Equal(t, []string{"NEW", "OLD"}, tickers("/top"))
+	assert.Equal(t, []string{"OLD", "NEW"}, tickers("/top?half_life=0"))
+
+	// A una fecha pasada solo cuentan los ratings publicados hasta entonces
+	asOf := now.AddDate(0, -6, 0).Format("2006-01-02")
+	assert.Equal(t, []string{"OLD"}, tickers("/top?as_of="+asOf))
+
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/top?as_of=ayer").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/top?as_of=2999-01-01").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/top?half_life=mucho").Code)
+}
+
+func TestRequireReady(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := service.NewReadiness()
+
+	r := gin.Default()
+	r.GET("/health/ready", GetReadiness(readiness))
+	stocks := r.Group("/stocks", RequireReady(readiness))
+	stocks.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/stocks/", "/health/ready"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+		assert.Equal(t, "5", w.Header().Get("Retry-After"), path)
+		assert.Contains(t, w.Body.String(), service.ReadinessWarmingUp, path)
+	}
+
+	readiness.MarkReady()
+	for _, path := range []string{"/stocks/", "/health/ready"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+func TestTriggerIngestion_WithoutSource(t *testing.T) {
+	db := setupTestDB(t)
+	router := gin.Default()
+	router.POST("/ingestions", TriggerIngestion(service.NewRefreshJob(db, 0, nil, nil)))
+
+	// Sin API externa no se devuelve un run ID que nunca se va a crear
+	w := performRequest(router, "POST", "/ingestions")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), `"id"`)
+}
+
+func TestScoringProfiles(t *testing.T) {
+	db := setupTestDB(t)
+	router := gin.Default()
+	router.GET("/top", GetTopInvestmentStocks(db, config.Scoring{}))
+	router.PUT("/profiles/:name", PutScoringProfile(db))
+	router.DELETE("/profiles/:name", DeleteScoringProfile(db))
+	router.GET("/profiles/:name/versions", GetScoringProfileHistory(db))
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/profiles/neutral", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/yaml")
+		req.Header.Set(ChangedByHeader, "ana")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Un perfil que premia los ratings neutrales pone a GOOG primero
+	resp := put("growth: 1\nratings:\n  Neutral: 100\n")
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	resp = performRequest(router, "GET", "/top?profile=neutral")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	if assert.NotEmpty(t, body) {
+		assert.Equal(t, "GOOG", body[0]["Ticker"])
+	}
+
+	// Los cambios aplican en la siguiente petición
+	assert.Equal(t, http.StatusOK, put(`{"growth": 1, "ratings": {"buy": 100}}`).Code)
+	resp = performRequest(router, "GET", "/top?profile=neutral")
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	if assert.NotEmpty(t, body) {
+		assert.Equal(t, "AAPL", body[0]["Ticker"])
+	}
+
+	assert.Equal(t, http.StatusBadRequest, put("growht: 1\n").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/top?profile=neutral&strategy=default").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/top?profile=nope").Code)
+
+	assert.Equal(t, http.StatusNoContent, performRequest(router, "DELETE", "/profiles/neutral").Code)
+	assert.Equal(t, http.StatusNotFound, performRequest(router, "DELETE", "/profiles/neutral").Code)
+
+	resp = performRequest(router, "GET", "/profiles/neutral/versions")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var versions []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &versions))
+	if assert.Len(t, versions, 3) {
+		assert.Equal(t, true, versions[0]["deleted"])
+		assert.Equal(t, "ana", versions[2]["changed_by"])
+	}
+}
+
+func TestStocksConsensus(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now().UTC()
+	_, err := db.NewInsert().Model(&[]models.StockItem{
+		{Ticker: "AAPL", Brokerage: "Morgan", RatingTo: "Neutral", TargetFrom: "$150", TargetTo: "$160", Action: "upgraded by", Time: now.AddDate(0, 0, -2)},
+		{Ticker: "AAPL", Brokerage: "Goldman", RatingTo: "Buy", TargetFrom: "$150", TargetTo: "$200", Action: "upgraded by", Time: now.AddDate(0, 0, -1)},
+	}).Exec(contextBackground())
+	assert.NoError(t, err)
+
+	router := gin.Default()
+	router.GET("/stocks/consensus", GetStocksConsensus(db, config.Scoring{}))
+	router.GET("/stocks/:ticker/consensus", GetTickerConsensus(db, config.Scoring{}))
+
+	// GOOG solo tiene una corredora y no llega al mínimo por defecto
+	resp := performRequest(router, "GET", "/stocks/consensus")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var list []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "AAPL", list[0]["ticker"])
+		assert.Equal(t, float64(2), list[0]["brokerages"])
+		assert.Equal(t, float64(2), list[0]["net_upgrades"])
+	}
+
+	resp = performRequest(router, "GET", "/stocks/consensus?min_brokerages=1&limit=1")
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Len(t, list, 1)
+
+	resp = performRequest(router, "GET", "/stocks/aapl/consensus")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var one map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &one))
+	assert.Equal(t, "AAPL", one["ticker"])
+	assert.Equal(t, float64(1), one["buy"])
+	assert.Equal(t, float64(1), one["hold"])
+	target := one["target"].(map[string]interface{})
+	assert.Equal(t, float64(200), target["high"])
+	assert.Equal(t, float64(180), target["median"])
+
+	// Con max_age solo cuenta el rating de Goldman de ayer
+	resp = performRequest(router, "GET", "/stocks/aapl/consensus?max_age=36h")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	one = nil
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &one))
+	assert.Equal(t, float64(1), one["brokerages"])
+	assert.Equal(t, float64(0), one["hold"])
+
+	assert.Equal(t, http.StatusNotFound, performRequest(router, "GET", "/stocks/MSFT/consensus").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/stocks/consensus?max_age=-1h").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/stocks/consensus?window=siempre").Code)
+	assert.Equal(t, http.StatusBadRequest, performRequest(router, "GET", "/stocks/consensus?min_brokerages=0").Code)
+}
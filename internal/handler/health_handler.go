@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// retryAfterSeconds es la espera sugerida a los clientes mientras el servicio arranca
+const retryAfterSeconds = "5"
+
+// GetLiveness responde 200 mientras el proceso esté vivo
+func GetLiveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive"})
+	}
+}
+
+// GetReadiness responde 200 cuando la carga inicial terminó y 503 mientras tanto
+func GetReadiness(readiness *service.Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := readiness.Status()
+		if !status.Ready {
+			c.Header("Retry-After", retryAfterSeconds)
+			c.JSON(http.StatusServiceUnavailable, status)
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// RequireReady corta con 503 las peticiones que llegan antes de que termine la carga inicial
+func RequireReady(readiness *service.Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := readiness.Status()
+		if !status.Ready {
+			c.Header("Retry-After", retryAfterSeconds)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":  "El servicio se está iniciando, intenta de nuevo en unos segundos",
+				"status": status.Status,
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+// maxImportSize limita el tamaño del archivo subido
+const maxImportSize = 10 << 20
+
+func ImportStocks(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo 'file' es requerido (máximo 10 MB)"})
+			return
+		}
+
+		format := strings.ToLower(c.PostForm("format"))
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+		}
+
+		var mapping service.ColumnMapping
+		if raw := c.PostForm("mapping"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'mapping' debe ser un objeto JSON campo → columna"})
+				return
+			}
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+			return
+		}
+		defer file.Close()
+
+		rows, err := service.ParseImportFile(file, format, mapping, c.PostForm("sheet"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := service.ImportStocks(c, db, rows)
+		if errors.Is(err, service.ErrInvalidImport) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  "Ninguna fila fue importada porque hay filas inválidas",
+				"result": result,
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando las filas importadas"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
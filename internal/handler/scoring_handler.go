@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/scoring"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+// maxProfileSize limita el tamaño de un perfil de pesos
+const maxProfileSize = 64 << 10
+
+// ChangedByHeader identifica a quién hace un cambio en los perfiles de scoring
+const ChangedByHeader = "X-User"
+
+func ListScoringProfiles(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profiles, err := service.ListScoringProfiles(c, db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los perfiles"})
+			return
+		}
+		c.JSON(http.StatusOK, profiles)
+	}
+}
+
+func GetScoringProfile(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profile, err := service.GetScoringProfile(c, db, c.Param("name"))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfil no encontrado"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el perfil"})
+			return
+		}
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// PutScoringProfile crea o reemplaza los pesos de un perfil. El cuerpo puede
+// ser YAML o JSON con los campos de scoring.Weights.
+func PutScoringProfile(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxProfileSize))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el perfil (máximo 64 KB)"})
+			return
+		}
+		weights, err := scoring.DecodeWeights(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		profile, created, err := service.SaveScoringProfile(c, db, c.Param("name"), weights, changedBy(c))
+		switch {
+		case errors.Is(err, service.ErrInvalidProfileName):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrProfileConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el perfil"})
+		case created:
+			c.JSON(http.StatusCreated, profile)
+		default:
+			c.JSON(http.StatusOK, profile)
+		}
+	}
+}
+
+func DeleteScoringProfile(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := service.DeleteScoringProfile(c, db, c.Param("name"), changedBy(c))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfil no encontrado"})
+		case errors.Is(err, service.ErrProfileConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo borrar el perfil"})
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+func GetScoringProfileHistory(db *bun.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		versions, err := service.ScoringProfileHistory(c, db, c.Param("name"))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfil no encontrado"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el historial"})
+			return
+		}
+		c.JSON(http.StatusOK, versions)
+	}
+}
+
+// changedBy devuelve el autor del cambio según ChangedByHeader, o la IP si no viene
+func changedBy(c *gin.Context) string {
+	if user := c.GetHeader(ChangedByHeader); user != "" {
+		return user
+	}
+	return c.ClientIP()
+}
+
+// topParams son los parámetros comunes de los endpoints de top
+type topParams struct {
+	scorer  scoring.Scorer // ya con el decaimiento aplicado
+	explain bool
+	asOf    time.Time // cero si no se pidió una fecha de corte
+}
+
+// parseTopParams lee strategy/profile, explain, as_of y half_life. El
+// decaimiento se mide hasta as_of, o hasta ahora si no viene, con la vida
+// media de half_life o la configurada. Si algo es inválido responde el error
+// y devuelve false.
+func parseTopParams(c *gin.Context, db *bun.DB, cfg config.Scoring) (topParams, bool) {
+	var p topParams
+	scorer, ok := resolveScorer(c, db)
+	if !ok {
+		return p, false
+	}
+	if p.explain, ok = boolQuery(c, "explain"); !ok {
+		return p, false
+	}
+
+	reference := time.Now()
+	if raw, present := c.GetQuery("as_of"); present {
+		asOf, err := scoring.ParseAsOf(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return p, false
+		}
+		if asOf.After(reference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'as_of' no puede ser una fecha futura"})
+			return p, false
+		}
+		p.asOf, reference = asOf, asOf
+	}
+
+	halfLife := cfg.HalfLife
+	if raw, present := c.GetQuery("half_life"); present {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'half_life' debe ser una duración como 720h (0 sin decaimiento)"})
+			return p, false
+		}
+		halfLife = d
+	}
+
+	p.scorer = scoring.WithDecay(scorer, halfLife, reference)
+	return p, true
+}
+
+// filter deja solo los ratings publicados hasta as_of
+func (p topParams) filter(q *bun.SelectQuery) *bun.SelectQuery {
+	if !p.asOf.IsZero() {
+		q = q.Where("time <= ?", p.asOf)
+	}
+	return q
+}
+
+// rank puntúa y ordena los ratings, con el desglose si se pidió
+func (p topParams) rank(stocks []models.StockItem, top int) []scoring.Scored {
+	scored := scoring.Rank(stocks, p.scorer, top)
+	if p.explain {
+		scoring.Explain(scored, p.scorer)
+	}
+	return scored
+}
+
+// resolveScorer elige el scorer de los endpoints de top: el perfil de pesos
+// del parámetro profile o la estrategia del parámetro strategy. Si no puede,
+// responde el error y devuelve false.
+func resolveScorer(c *gin.Context, db *bun.DB) (scoring.Scorer, bool) {
+	name, hasProfile := c.GetQuery("profile")
+	if !hasProfile {
+		scorer, err := scoring.Get(c.DefaultQuery("strategy", scoring.Default))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		return scorer, true
+	}
+
+	if _, ok := c.GetQuery("strategy"); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Usa 'strategy' o 'profile', no ambos"})
+		return nil, false
+	}
+	scorer, err := service.ProfileScorer(c, db, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Perfil de scoring desconocido: " + name})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cargar el perfil de scoring"})
+		return nil, false
+	}
+	return scorer, true
+}
+
+// boolQuery lee un parámetro booleano opcional (false si no viene). Si el
+// valor es inválido responde 400 y devuelve false en ok.
+func boolQuery(c *gin.Context, key string) (value, ok bool) {
+	raw, present := c.GetQuery(key)
+	if !present {
+		return false, true
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El parámetro '%s' debe ser true o false", key)})
+		return false, false
+	}
+	return value, true
+}
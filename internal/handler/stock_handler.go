@@ -4,21 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/database"
 	"github.com/Carlosmercg/stock-analyzer/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
 )
 
-type StockScore struct {
-	models.StockItem
-	Score float64 `json:"score"`
-}
-
 func GetAllStocks(db *bun.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pageStr := c.DefaultQuery("page", "1")
@@ -79,13 +74,23 @@ func GetFilteredStocks(db *bun.DB) gin.HandlerFunc {
 			baseQuery = baseQuery.Where("action = ?", action)
 		}
 		if min := c.Query("target_min"); min != "" {
-			baseQuery = baseQuery.Where("CAST(REPLACE(target_to, '$', '') AS FLOAT) >= ?", min)
+			value, err := parseDollar(min)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'target_min' no es un precio válido"})
+				return
+			}
+			baseQuery = baseQuery.Where("target_to_value >= ?", value)
 		}
 		if max := c.Query("target_max"); max != "" {
-			baseQuery = baseQuery.Where("CAST(REPLACE(target_to, '$', '') AS FLOAT) <= ?", max)
+			value, err := parseDollar(max)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'target_max' no es un precio válido"})
+				return
+			}
+			baseQuery = baseQuery.Where("target_to_value <= ?", value)
 		}
 		if company := c.Query("company"); company != "" {
-			baseQuery = baseQuery.Where("company ILIKE ?", company+"%")
+			baseQuery = baseQuery.Where(database.ILike(db, "company"), company+"%")
 		}
 
 		// 🔃 Orden dinámico por fecha
@@ -128,67 +133,38 @@ func GetFilteredStocks(db *bun.DB) gin.HandlerFunc {
 	}
 }
 
-func GetTopInvestmentStocks(db *bun.DB) gin.HandlerFunc {
+func GetTopInvestmentStocks(db *bun.DB, cfg config.Scoring) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		params, ok := parseTopParams(c, db, cfg)
+		if !ok {
+			return
+		}
+
 		var stocks []models.StockItem
-		err := db.NewSelect().Model(&stocks).Scan(c)
+		err := params.filter(db.NewSelect().Model(&stocks)).Scan(c)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error cargando los datos"})
 			return
 		}
 
-		// Calcular puntuaciones
-		scored := make([]StockScore, 0, len(stocks))
-		for _, s := range stocks {
-			to, errTo := parseDollar(s.TargetTo)
-			from, errFrom := parseDollar(s.TargetFrom)
-			if errTo != nil || errFrom != nil || from == 0 {
-				continue
-			}
-
-			growth := (to - from) / from * 100
-
-			score := growth
-			if strings.ToLower(s.RatingTo) == "buy" || strings.ToLower(s.RatingTo) == "outperform" {
-				score += 10
-			}
-			if strings.Contains(strings.ToLower(s.Action), "raised") {
-				score += 5
-			} else if strings.Contains(strings.ToLower(s.Action), "initiated") {
-				score += 2
-			} else if strings.Contains(strings.ToLower(s.Action), "downgraded") {
-				score -= 5
-			}
-
-			scored = append(scored, StockScore{StockItem: s, Score: score})
-		}
-
-		// Ordenar descendente por score
-		sort.Slice(scored, func(i, j int) bool {
-			return scored[i].Score > scored[j].Score
-		})
-
-		// Tomar top 20
-		limit := 20
-		if len(scored) < 20 {
-			limit = len(scored)
-		}
-
-		c.JSON(http.StatusOK, scored[:limit])
+		c.JSON(http.StatusOK, params.rank(stocks, 20))
 	}
 }
 
-func GetTopStocksByBrokerage(db *bun.DB) gin.HandlerFunc {
+func GetTopStocksByBrokerage(db *bun.DB, cfg config.Scoring) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		brokerageParam := c.Query("brokerage")
 		if brokerageParam == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'brokerage' es requerido"})
 			return
 		}
+		params, ok := parseTopParams(c, db, cfg)
+		if !ok {
+			return
+		}
 
 		var stocks []models.StockItem
-		err := db.NewSelect().
-			Model(&stocks).
+		err := params.filter(db.NewSelect().Model(&stocks)).
 			Where("LOWER(brokerage) = LOWER(?)", brokerageParam).
 			Scan(c)
 
@@ -197,44 +173,7 @@ func GetTopStocksByBrokerage(db *bun.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Calcular puntuaciones
-		scored := make([]StockScore, 0, len(stocks))
-		for _, s := range stocks {
-			to, errTo := parseDollar(s.TargetTo)
-			from, errFrom := parseDollar(s.TargetFrom)
-			if errTo != nil || errFrom != nil || from == 0 {
-				continue
-			}
-
-			growth := (to - from) / from * 100
-
-			score := growth
-			if strings.ToLower(s.RatingTo) == "buy" || strings.ToLower(s.RatingTo) == "outperform" {
-				score += 10
-			}
-			if strings.Contains(strings.ToLower(s.Action), "raised") {
-				score += 5
-			} else if strings.Contains(strings.ToLower(s.Action), "initiated") {
-				score += 2
-			} else if strings.Contains(strings.ToLower(s.Action), "downgraded") {
-				score -= 5
-			}
-
-			scored = append(scored, StockScore{StockItem: s, Score: score})
-		}
-
-		// Ordenar descendente por score
-		sort.Slice(scored, func(i, j int) bool {
-			return scored[i].Score > scored[j].Score
-		})
-
-		// Top 10 o menos si hay pocos
-		limit := 10
-		if len(scored) < 10 {
-			limit = len(scored)
-		}
-
-		c.JSON(http.StatusOK, scored[:limit])
+		c.JSON(http.StatusOK, params.rank(stocks, 10))
 	}
 }
 
@@ -276,7 +215,7 @@ func GetDistinctRatings(db *bun.DB) gin.HandlerFunc {
 	}
 }
 
-func GetCompanyInfoFromFinnhub() gin.HandlerFunc {
+func GetCompanyInfoFromFinnhub(cfg config.Finnhub) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ticker := c.Query("ticker")
 		if ticker == "" {
@@ -284,8 +223,8 @@ func GetCompanyInfoFromFinnhub() gin.HandlerFunc {
 			return
 		}
 
-		apiKey := os.Getenv("FINNHUB_APIKEY")
-		urlTemplate := os.Getenv("FINNHUB_URL")
+		apiKey := cfg.APIKey
+		urlTemplate := cfg.URL
 
 		if apiKey == "" || urlTemplate == "" {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falta configuración de Finnhub"})
@@ -334,7 +273,6 @@ func GetCompanyInfoFromFinnhub() gin.HandlerFunc {
 }
 
 func parseDollar(s string) (float64, error) {
-	s = strings.ReplaceAll(s, "$", "")
-	s = strings.ReplaceAll(s, ",", "")
-	return strconv.ParseFloat(s, 64)
+	value, _, err := models.ParsePrice(s)
+	return value, err
 }
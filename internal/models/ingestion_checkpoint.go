@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// IngestionCheckpoint guarda el progreso de una sincronización para poder
+// retomarla desde la última página escrita
+type IngestionCheckpoint struct {
+	bun.BaseModel `bun:"table:ingestion_checkpoints"`
+
+	RunID       string    `bun:"run_id,pk"`
+	Mode        string    `bun:"mode,notnull"`
+	Since       time.Time `bun:"since,nullzero,type:timestamptz"` // corte de la sincronización incremental
+	NextPage    string    `bun:"next_page,notnull"`               // token de la siguiente página a descargar
+	Page        int       `bun:"page,notnull"`                    // última página escrita
+	RowsWritten int       `bun:"rows_written,notnull"`
+	Completed   bool      `bun:"completed,notnull"`
+	UpdatedAt   time.Time `bun:"updated_at,notnull,type:timestamptz"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Estados posibles de una ejecución de ingesta
+const (
+	IngestionRunning   = "running"
+	IngestionSucceeded = "succeeded"
+	IngestionFailed    = "failed"
+)
+
+// IngestionRun es el historial de cada sincronización con la API externa.
+// Comparte el ID con su IngestionCheckpoint.
+type IngestionRun struct {
+	bun.BaseModel `bun:"table:ingestion_runs"`
+
+	ID         string    `bun:"id,pk" json:"id"`
+	Trigger    string    `bun:"trigger,notnull" json:"trigger"` // startup, schedule, manual
+	Mode       string    `bun:"mode,notnull" json:"mode"`
+	Status     string    `bun:"status,notnull" json:"status"`
+	StartedAt  time.Time `bun:"started_at,notnull,type:timestamptz" json:"started_at"`
+	FinishedAt time.Time `bun:"finished_at,nullzero,type:timestamptz" json:"finished_at,omitzero"`
+	Pages      int       `bun:"pages,notnull" json:"pages"`
+	Inserted   int       `bun:"inserted,notnull" json:"inserted"`
+	Updated    int       `bun:"updated,notnull" json:"updated"`
+	Skipped    int       `bun:"skipped,notnull" json:"skipped"`
+	Rejected   int       `bun:"rejected,notnull" json:"rejected"`
+	Error      string    `bun:"error,notnull" json:"error,omitempty"`
+}
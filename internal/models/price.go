@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency se asume cuando el precio no trae símbolo de moneda
+const DefaultCurrency = "USD"
+
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+}
+
+// ParsePrice convierte un precio objetivo como "$1,150.00" en su valor numérico
+// y el código de moneda
+func ParsePrice(s string) (float64, string, error) {
+	raw := strings.TrimSpace(s)
+	currency := DefaultCurrency
+
+	for symbol, code := range currencySymbols {
+		if strings.HasPrefix(raw, symbol) {
+			raw = strings.TrimPrefix(raw, symbol)
+			currency = code
+			break
+		}
+	}
+
+	raw = strings.ReplaceAll(raw, ",", "")
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("precio inválido %q", s)
+	}
+	return value, currency, nil
+}
+
+// Amount es un valor NUMERIC. Acepta los tipos que devuelve cada driver
+// (texto en Postgres/Cockroach, enteros o reales en SQLite).
+type Amount float64
+
+var (
+	_ sql.Scanner   = (*Amount)(nil)
+	_ driver.Valuer = Amount(0)
+)
+
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case float64:
+		*a = Amount(v)
+	case int64:
+		*a = Amount(v)
+	case []byte:
+		return a.Scan(string(v))
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("valor numérico inválido %q", v)
+		}
+		*a = Amount(f)
+	default:
+		return fmt.Errorf("no se puede leer %T como Amount", src)
+	}
+	return nil
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return float64(a), nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ScoringProfile es un perfil de pesos de scoring con nombre, editable por
+// API. Weights es el JSON de scoring.Weights.
+type ScoringProfile struct {
+	bun.BaseModel `bun:"table:scoring_profiles"`
+
+	Name      string          `bun:"name,pk" json:"name"`
+	Version   int             `bun:"version,notnull" json:"version"`
+	Weights   json.RawMessage `bun:"weights,notnull,type:jsonb" json:"weights"`
+	UpdatedBy string          `bun:"updated_by,notnull" json:"updated_by"`
+	UpdatedAt time.Time       `bun:"updated_at,notnull,type:timestamptz" json:"updated_at"`
+}
+
+// ScoringProfileVersion es el historial de cambios de un perfil: cada
+// creación, edición o borrado agrega una fila con los pesos resultantes
+type ScoringProfileVersion struct {
+	bun.BaseModel `bun:"table:scoring_profile_versions"`
+
+	ID        int64           `bun:",pk,autoincrement" json:"-"`
+	Name      string          `bun:"name,notnull,unique:scoring_profile_versions_name_version" json:"name"`
+	Version   int             `bun:"version,notnull,unique:scoring_profile_versions_name_version" json:"version"`
+	Weights   json.RawMessage `bun:"weights,notnull,type:jsonb" json:"weights"` // en un borrado, los pesos que tenía
+	Deleted   bool            `bun:"deleted,notnull" json:"deleted"`
+	ChangedBy string          `bun:"changed_by,notnull" json:"changed_by"`
+	ChangedAt time.Time       `bun:"changed_at,notnull,type:timestamptz" json:"changed_at"`
+}
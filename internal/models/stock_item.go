@@ -1,22 +1,64 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"github.com/uptrace/bun"
 )
 
+// StockItemNaturalKey son las columnas que identifican un rating de forma única,
+// usadas en la restricción UNIQUE y en los upserts del loader
+const StockItemNaturalKey = "ticker, brokerage, time, action, rating_from, rating_to, target_from, target_to"
+
+// TimePrecision es la precisión con la que se guarda time: timestamptz (y el
+// formato de bun en SQLite) llega a microsegundos. Los items se truncan a
+// esta precisión al convertirlos para que la clave natural coincida con la
+// almacenada.
+const TimePrecision = time.Microsecond
+
 type StockItem struct {
 	bun.BaseModel `bun:"table:stock_items"`
 
 	ID         int64     `bun:",pk,autoincrement"` // Primary key
-	Ticker     string    `bun:"ticker,notnull"`
-	TargetFrom string    `bun:"target_from,notnull"`
-	TargetTo   string    `bun:"target_to,notnull"`
+	Ticker     string    `bun:"ticker,notnull,unique:stock_items_natural_key"`
+	TargetFrom string    `bun:"target_from,notnull,unique:stock_items_natural_key"`
+	TargetTo   string    `bun:"target_to,notnull,unique:stock_items_natural_key"`
 	Company    string    `bun:"company,notnull"`
-	Action     string    `bun:"action,notnull"`
-	Brokerage  string    `bun:"brokerage,notnull"`
-	RatingFrom string    `bun:"rating_from,notnull"`
-	RatingTo   string    `bun:"rating_to,notnull"`
-	Time       time.Time `bun:"time,notnull,type:timestamptz"` // Usa el tipo adecuado de CockroachDB
+	Action     string    `bun:"action,notnull,unique:stock_items_natural_key"`
+	Brokerage  string    `bun:"brokerage,notnull,unique:stock_items_natural_key"`
+	RatingFrom string    `bun:"rating_from,notnull,unique:stock_items_natural_key"`
+	RatingTo   string    `bun:"rating_to,notnull,unique:stock_items_natural_key"`
+	Time       time.Time `bun:"time,notnull,type:timestamptz,unique:stock_items_natural_key"` // Usa el tipo adecuado de CockroachDB
+
+	// Valores numéricos de TargetFrom/TargetTo; nil si el texto no es un precio válido
+	TargetFromValue *Amount `bun:"target_from_value,type:numeric"`
+	TargetToValue   *Amount `bun:"target_to_value,type:numeric"`
+	Currency        string  `bun:"currency,notnull"`
+}
+
+var _ bun.BeforeAppendModelHook = (*StockItem)(nil)
+
+// BeforeAppendModel calcula las columnas numéricas al insertar, sea cual sea
+// el origen del item
+func (s *StockItem) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok {
+		s.NormalizeTargets()
+	}
+	return nil
+}
+
+// NormalizeTargets rellena TargetFromValue, TargetToValue y Currency a partir
+// de los precios en texto
+func (s *StockItem) NormalizeTargets() {
+	s.TargetFromValue, s.TargetToValue = nil, nil
+
+	if value, currency, err := ParsePrice(s.TargetFrom); err == nil {
+		s.TargetFromValue = (*Amount)(&value)
+		s.Currency = currency
+	}
+	if value, currency, err := ParsePrice(s.TargetTo); err == nil {
+		s.TargetToValue = (*Amount)(&value)
+		s.Currency = currency
+	}
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// StockItemRejected guarda los items de la API que no pasaron la validación,
+// con el JSON original y el motivo, para reportarlos al proveedor
+type StockItemRejected struct {
+	bun.BaseModel `bun:"table:stock_items_rejected"`
+
+	ID        int64           `bun:",pk,autoincrement" json:"id"`
+	RunID     string          `bun:"run_id,notnull" json:"run_id"`
+	Page      int             `bun:"page,notnull" json:"page"`
+	Reason    string          `bun:"reason,notnull" json:"reason"`
+	Raw       json.RawMessage `bun:"raw,notnull,type:jsonb" json:"raw"`
+	CreatedAt time.Time       `bun:"created_at,notnull,type:timestamptz" json:"created_at"`
+}
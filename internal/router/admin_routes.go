@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/Carlosmercg/stock-analyzer/internal/handler"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+func RegisterAdminRoutes(r *gin.RouterGroup, db *bun.DB, job *service.RefreshJob) {
+	admin := r.Group("/admin")
+	{
+		admin.GET("/refresh", handler.GetRefreshStatus(job))
+		admin.GET("/ingestions", handler.GetIngestionRuns(db))
+		admin.GET("/ingestions/:id", handler.GetIngestionRun(db))
+		admin.GET("/ingestions/:id/rejects", handler.GetIngestionRejects(db))
+		admin.POST("/ingestions", handler.TriggerIngestion(job))
+	}
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/Carlosmercg/stock-analyzer/internal/handler"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHealthRoutes(r *gin.RouterGroup, readiness *service.Readiness) {
+	health := r.Group("/health")
+	{
+		health.GET("/live", handler.GetLiveness())
+		health.GET("/ready", handler.GetReadiness(readiness))
+	}
+}
@@ -3,26 +3,32 @@ package router
 import (
 	"time"
 
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/handler"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
 )
 
-func SetupRouter(db *bun.DB) *gin.Engine {
+func SetupRouter(cfg *config.Config, db *bun.DB, job *service.RefreshJob, readiness *service.Readiness) *gin.Engine {
 	router := gin.Default()
 
 	//  Configurar CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"}, // Cambia esto si es necesario
+		AllowOrigins:     cfg.Server.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", handler.ChangedByHeader},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	api := router.Group("/api")
-	RegisterStockRoutes(api, db)
+	RegisterHealthRoutes(api, readiness)
+	RegisterStockRoutes(api, cfg, db, readiness)
+	RegisterAdminRoutes(api, db, job)
+	RegisterScoringRoutes(api, db)
 
 	return router
 }
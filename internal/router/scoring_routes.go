@@ -0,0 +1,18 @@
+package router
+
+import (
+	"github.com/Carlosmercg/stock-analyzer/internal/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+func RegisterScoringRoutes(r *gin.RouterGroup, db *bun.DB) {
+	profiles := r.Group("/scoring/profiles")
+	{
+		profiles.GET("", handler.ListScoringProfiles(db))
+		profiles.GET("/:name", handler.GetScoringProfile(db))
+		profiles.GET("/:name/versions", handler.GetScoringProfileHistory(db))
+		profiles.PUT("/:name", handler.PutScoringProfile(db))
+		profiles.DELETE("/:name", handler.DeleteScoringProfile(db))
+	}
+}
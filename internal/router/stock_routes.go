@@ -1,21 +1,32 @@
 package router
 
 import (
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
 	"github.com/Carlosmercg/stock-analyzer/internal/handler"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
 )
 
-func RegisterStockRoutes(r *gin.RouterGroup, db *bun.DB) {
+func RegisterStockRoutes(r *gin.RouterGroup, cfg *config.Config, db *bun.DB, readiness *service.Readiness) {
 	stock := r.Group("/stocks")
 	{
-		stock.GET("/", handler.GetAllStocks(db))
-		stock.GET("/filter", handler.GetFilteredStocks(db))
-		stock.GET("/top", handler.GetTopInvestmentStocks(db))
-		stock.GET("/top-by-brokerage", handler.GetTopStocksByBrokerage(db))
-		stock.GET("/brokerages", handler.GetDistinctBrokerages(db))
-		stock.GET("/ratings", handler.GetDistinctRatings(db))
-		stock.GET("/company/info", handler.GetCompanyInfoFromFinnhub())
+		// La importación y el proxy a Finnhub no dependen de los datos
+		// cargados: importar es justamente una forma de llenar la base vacía
+		stock.GET("/company/info", handler.GetCompanyInfoFromFinnhub(cfg.Finnhub))
+		stock.POST("/import", handler.ImportStocks(db))
+	}
 
+	// Lecturas y rankings: 503 hasta que termine la carga inicial
+	data := stock.Group("", handler.RequireReady(readiness))
+	{
+		data.GET("/", handler.GetAllStocks(db))
+		data.GET("/filter", handler.GetFilteredStocks(db))
+		data.GET("/top", handler.GetTopInvestmentStocks(db, cfg.Scoring))
+		data.GET("/top-by-brokerage", handler.GetTopStocksByBrokerage(db, cfg.Scoring))
+		data.GET("/consensus", handler.GetStocksConsensus(db, cfg.Scoring))
+		data.GET("/:ticker/consensus", handler.GetTickerConsensus(db, cfg.Scoring))
+		data.GET("/brokerages", handler.GetDistinctBrokerages(db))
+		data.GET("/ratings", handler.GetDistinctRatings(db))
 	}
 }
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterStockRoutes_ImportDoesNotWaitForData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterStockRoutes(r.Group("/api"), &config.Config{}, nil, service.NewReadiness())
+
+	request := func(method, path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Mientras no hay datos las lecturas responden 503...
+	assert.Equal(t, http.StatusServiceUnavailable, request("GET", "/api/stocks/top"))
+	assert.Equal(t, http.StatusServiceUnavailable, request("GET", "/api/stocks/AAPL/consensus"))
+	// ...pero importar y consultar Finnhub no esperan a la carga inicial
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/api/stocks/import"))
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/api/stocks/company/info"))
+}
@@ -0,0 +1,83 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+)
+
+// Decay multiplica el score de otro Scorer por 0.5^(edad/HalfLife), donde la
+// edad del rating se mide hasta AsOf, para que los ratings recientes dominen
+type Decay struct {
+	Scorer   Scorer
+	HalfLife time.Duration
+	AsOf     time.Time
+}
+
+// WithDecay envuelve scorer con decaimiento; halfLife <= 0 lo devuelve sin cambios
+func WithDecay(scorer Scorer, halfLife time.Duration, asOf time.Time) Scorer {
+	if halfLife <= 0 {
+		return scorer
+	}
+	return Decay{Scorer: scorer, HalfLife: halfLife, AsOf: asOf}
+}
+
+// Factor es el multiplicador para un rating de la fecha t; los ratings
+// posteriores a AsOf no decaen
+func (d Decay) Factor(t time.Time) float64 {
+	age := max(d.AsOf.Sub(t), 0)
+	return math.Exp2(-float64(age) / float64(d.HalfLife))
+}
+
+func (d Decay) Score(s models.StockItem) (float64, bool) {
+	score, ok := d.Scorer.Score(s)
+	if !ok {
+		return 0, false
+	}
+	return score * d.Factor(s.Time), true
+}
+
+// Explain desglosa el scorer envuelto y agrega el ajuste por antigüedad
+func (d Decay) Explain(s models.StockItem) ([]Component, bool) {
+	score, ok := d.Scorer.Score(s)
+	if !ok {
+		return nil, false
+	}
+	components := explainOne(d.Scorer, s, score)
+
+	factor := d.Factor(s.Time)
+	components = append(components, Component{
+		Name:  "decay",
+		Value: score*factor - score,
+		Rule: fmt.Sprintf("rating del %s, %s antes de %s, vida media %s: × %.3f",
+			s.Time.UTC().Format("2006-01-02"), formatDays(max(d.AsOf.Sub(s.Time), 0)),
+			d.AsOf.UTC().Format("2006-01-02"), formatDays(d.HalfLife), factor),
+	})
+	return components, true
+}
+
+// formatDays muestra una duración en días, que es como se piensa la antigüedad de un rating
+func formatDays(d time.Duration) string {
+	days := d.Hours() / 24
+	if days == math.Trunc(days) {
+		return fmt.Sprintf("%.0f días", days)
+	}
+	return fmt.Sprintf("%.1f días", days)
+}
+
+// ParseAsOf interpreta una fecha de corte en RFC 3339 o AAAA-MM-DD. Una fecha
+// sin hora incluye todo ese día (UTC).
+func ParseAsOf(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fecha de corte inválida %q: usa AAAA-MM-DD o RFC 3339", raw)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
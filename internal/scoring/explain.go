@@ -0,0 +1,39 @@
+package scoring
+
+import "github.com/Carlosmercg/stock-analyzer/internal/models"
+
+// Component es un término del score con la regla que lo produjo. La suma de
+// los Value de un desglose es el score.
+type Component struct {
+	Name  string  `json:"name"`  // upside, rating, action, score_cap...
+	Value float64 `json:"value"` // aporte al score
+	Rule  string  `json:"rule"`  // regla aplicada, legible por una persona
+}
+
+// Explainer es un Scorer que puede desglosar su puntuación
+type Explainer interface {
+	Scorer
+	Explain(s models.StockItem) ([]Component, bool)
+}
+
+// Explain agrega el desglose a cada rating ya puntuado. Si el scorer no
+// implementa Explainer el desglose es un único componente con el score.
+func Explain(scored []Scored, scorer Scorer) {
+	for i := range scored {
+		scored[i].Explanation = explainOne(scorer, scored[i].StockItem, scored[i].Score)
+	}
+}
+
+// explainOne desglosa un rating cuyo score ya se conoce
+func explainOne(scorer Scorer, s models.StockItem, score float64) []Component {
+	if explainer, ok := scorer.(Explainer); ok {
+		if components, ok := explainer.Explain(s); ok {
+			return components
+		}
+	}
+	return []Component{{
+		Name:  "score",
+		Value: score,
+		Rule:  "la estrategia no desglosa su puntuación",
+	}}
+}
@@ -0,0 +1,104 @@
+// Package scoring puntúa los ratings de los analistas para armar rankings de
+// inversión. Cada estrategia implementa Scorer y se registra con un nombre;
+// los handlers la eligen con el parámetro strategy sin conocerla.
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+)
+
+// Default es la estrategia que se usa si no se indica otra
+const Default = "default"
+
+// ErrUnknownStrategy se devuelve al pedir una estrategia no registrada
+var ErrUnknownStrategy = errors.New("estrategia de puntuación desconocida")
+
+// Scorer puntúa un rating. Devuelve false si el rating no se puede puntuar,
+// por ejemplo porque no tiene precios objetivo válidos.
+type Scorer interface {
+	Score(s models.StockItem) (float64, bool)
+}
+
+// ScorerFunc permite usar una función como Scorer
+type ScorerFunc func(s models.StockItem) (float64, bool)
+
+func (f ScorerFunc) Score(s models.StockItem) (float64, bool) {
+	return f(s)
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Scorer)
+)
+
+// Register agrega una estrategia con el nombre dado. Entra en pánico si el
+// nombre ya está registrado, igual que database/sql con los drivers.
+func Register(name string, scorer Scorer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("scoring: estrategia registrada dos veces: " + name)
+	}
+	registry[name] = scorer
+}
+
+// Get devuelve la estrategia registrada con ese nombre
+func Get(name string) (Scorer, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	scorer, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q: usa %s", ErrUnknownStrategy, name, strings.Join(namesLocked(), ", "))
+	}
+	return scorer, nil
+}
+
+// Names devuelve los nombres de las estrategias registradas, ordenados
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scored es un rating con su puntuación
+type Scored struct {
+	models.StockItem
+	Score       float64     `json:"score"`
+	Explanation []Component `json:"explanation,omitempty"` // solo si se pidió con Explain
+}
+
+// Rank puntúa los ratings y devuelve los top mejores, de mayor a menor.
+// Los ratings que el scorer no puede puntuar se omiten; top <= 0 devuelve todos.
+func Rank(stocks []models.StockItem, scorer Scorer, top int) []Scored {
+	scored := make([]Scored, 0, len(stocks))
+	for _, s := range stocks {
+		if score, ok := scorer.Score(s); ok {
+			scored = append(scored, Scored{StockItem: s, Score: score})
+		}
+	}
+
+	// Ordenar descendente por score; a igual score se mantiene el orden de entrada
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	if top > 0 && len(scored) > top {
+		scored = scored[:top]
+	}
+	return scored
+}
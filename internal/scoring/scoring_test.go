@@ -0,0 +1,233 @@
+package scoring
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func item(ticker, from, to, rating, action string) models.StockItem {
+	s := models.StockItem{Ticker: ticker, TargetFrom: from, TargetTo: to, RatingTo: rating, Action: action}
+	s.NormalizeTargets()
+	return s
+}
+
+func TestStrategies(t *testing.T) {
+	// +100% de subida, pero rebajado
+	bigUpside := item("BIG", "$10", "$20", "Hold", "downgraded by")
+	// +10% de subida con rating buy y objetivo subido
+	steady := item("STD", "$100", "$110", "Buy", "target raised by")
+
+	cases := map[string]struct{ big, steady float64 }{
+		"default":      {100 - 5, 10 + 10 + 5},
+		"upside-only":  {100, 10},
+		"conservative": {50*0.5 - 15, 10*0.5 + 5 + 3},
+	}
+	for name, want := range cases {
+		scorer, err := Get(name)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		got, ok := scorer.Score(bigUpside)
+		assert.True(t, ok)
+		assert.InDelta(t, want.big, got, 0.001, name)
+		got, _ = scorer.Score(steady)
+		assert.InDelta(t, want.steady, got, 0.001, name)
+	}
+
+	// La conservadora prefiere el rating estable, la de solo subida el contrario
+	conservative, _ := Get("conservative")
+	assert.Equal(t, "STD", Rank([]models.StockItem{bigUpside, steady}, conservative, 1)[0].Ticker)
+	upside, _ := Get("upside-only")
+	assert.Equal(t, "BIG", Rank([]models.StockItem{bigUpside, steady}, upside, 1)[0].Ticker)
+}
+
+// legacyScore es la fórmula que tenían los handlers de top antes del paquete scoring
+func legacyScore(s models.StockItem) float64 {
+	growth, _ := Growth(s)
+	score := growth
+	if strings.ToLower(s.RatingTo) == "buy" || strings.ToLower(s.RatingTo) == "outperform" {
+		score += 10
+	}
+	if strings.Contains(strings.ToLower(s.Action), "raised") {
+		score += 5
+	} else if strings.Contains(strings.ToLower(s.Action), "initiated") {
+		score += 2
+	} else if strings.Contains(strings.ToLower(s.Action), "downgraded") {
+		score -= 5
+	}
+	return score
+}
+
+func TestDefaultWeights_MatchesLegacyFormula(t *testing.T) {
+	actions := []string{
+		"target raised by", "initiated by", "downgraded by", "reiterated by", "",
+		"downgraded by, target raised by", "initiated by, downgraded by", "Target Raised By",
+	}
+	for _, action := range actions {
+		for _, rating := range []string{"Buy", "outperform", "Hold"} {
+			s := item("X", "$100", "$125", rating, action)
+			got, ok := DefaultWeights.Score(s)
+			assert.True(t, ok)
+			assert.InDelta(t, legacyScore(s), got, 0.001, "%s / %s", rating, action)
+		}
+	}
+}
+
+func TestRank_SkipsUnscorable(t *testing.T) {
+	noTargets := item("NOPE", "", "", "Buy", "initiated by")
+	scored := Rank([]models.StockItem{noTargets, item("OK", "$1", "$2", "", "")}, DefaultWeights, 0)
+	assert.Len(t, scored, 1)
+	assert.Equal(t, "OK", scored[0].Ticker)
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-constant", ScorerFunc(func(models.StockItem) (float64, bool) { return 1, true }))
+	assert.Contains(t, Names(), "test-constant")
+	assert.Panics(t, func() { Register("test-constant", DefaultWeights) })
+
+	_, err := Get("nope")
+	assert.True(t, errors.Is(err, ErrUnknownStrategy))
+	assert.Contains(t, err.Error(), "upside-only")
+}
+
+func TestDecodeWeights(t *testing.T) {
+	w, err := DecodeWeights([]byte("growth: 2\nscore_cap: 30\nratings:\n  BUY: 5\nactions:\n  raised: 1\n  target raised: 3\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 5.0, w.Ratings["buy"])
+
+	// La palabra más larga gana; el score se topa en 30
+	s := item("X", "$10", "$12", "Buy", "target raised by")
+	score, _ := w.Score(s)
+	assert.InDelta(t, 30, score, 0.001) // sin tope: 20*2 + 5 + 3 = 48
+	w.ScoreCap = 0
+	score, _ = w.Score(s)
+	assert.InDelta(t, 48, score, 0.001)
+
+	_, err = DecodeWeights([]byte(`{"growth": 1, "growht": 2}`))
+	assert.Error(t, err)
+	_, err = DecodeWeights([]byte("growth_cap: -1\n"))
+	assert.ErrorContains(t, err, "growth_cap no puede ser negativo")
+	_, err = DecodeWeights([]byte("ratings:\n  Buy: 1\n  buy: 2\n"))
+	assert.ErrorContains(t, err, "repetido")
+	_, err = DecodeWeights(nil)
+	assert.Error(t, err)
+
+	// action_order decide entre varias palabras antes que el largo
+	w, err = DecodeWeights([]byte("growth: 1\nactions:\n  raised: 1\n  target raised: 3\naction_order: [Raised]\n"))
+	if assert.NoError(t, err) {
+		score, _ = w.Score(item("X", "$10", "$12", "", "target raised by"))
+		assert.InDelta(t, 21, score, 0.001)
+	}
+	_, err = DecodeWeights([]byte("actions:\n  raised: 1\naction_order: [lowered]\n"))
+	assert.ErrorContains(t, err, "action_order.lowered no está en actions")
+	_, err = DecodeWeights([]byte("actions:\n  raised: 1\naction_order: [raised, raised]\n"))
+	assert.ErrorContains(t, err, "repetido")
+}
+
+func TestExplain(t *testing.T) {
+	s := item("AAPL", "$100", "$200", "Buy", "target raised by")
+	w := Weights{Growth: 1, GrowthCap: 50, ScoreCap: 60, Ratings: DefaultWeights.Ratings, Actions: DefaultWeights.Actions}
+
+	scored := Rank([]models.StockItem{s}, w, 0)
+	Explain(scored, w)
+	components := scored[0].Explanation
+	if !assert.Len(t, components, 4) {
+		return
+	}
+
+	var sum float64
+	for _, c := range components {
+		sum += c.Value
+	}
+	assert.InDelta(t, scored[0].Score, sum, 0.001)
+	assert.InDelta(t, 60, sum, 0.001)
+
+	assert.Equal(t, "upside", components[0].Name)
+	assert.Equal(t, "objetivo $100 → $200: +100.00%, topado a +50.00% × 1", components[0].Rule)
+	assert.Equal(t, `rating "Buy": +10`, components[1].Rule)
+	assert.Equal(t, `acción contiene "raised": +5`, components[2].Rule)
+	assert.Equal(t, "score_cap", components[3].Name)
+	assert.InDelta(t, -5, components[3].Value, 0.001)
+
+	// Un scorer sin desglose devuelve el score como único componente
+	constant := ScorerFunc(func(models.StockItem) (float64, bool) { return 7, true })
+	scored = Rank([]models.StockItem{s}, constant, 0)
+	Explain(scored, constant)
+	assert.Equal(t, []Component{{Name: "score", Value: 7, Rule: "la estrategia no desglosa su puntuación"}}, scored[0].Explanation)
+}
+
+func TestWeights_ScoreMatchesExplain(t *testing.T) {
+	capped := Weights{Growth: 1, GrowthCap: 50, ScoreCap: 60, Ratings: DefaultWeights.Ratings, Actions: DefaultWeights.Actions}
+	items := []models.StockItem{
+		item("AAPL", "$100", "$200", "Buy", "target raised by"),
+		item("GOOG", "$100", "$90", "Underweight", "downgraded by"),
+		item("MSFT", "$100", "$101", "Hold", "reiterated by"),
+		item("TSLA", "$10", "$0.50", "Outperform", "initiated by"),
+	}
+
+	for _, w := range []Weights{DefaultWeights, ConservativeWeights, capped} {
+		for _, s := range items {
+			score, ok := w.Score(s)
+			assert.True(t, ok)
+			components, ok := w.Explain(s)
+			assert.True(t, ok)
+
+			var sum float64
+			for _, c := range components {
+				sum += c.Value
+			}
+			assert.InDelta(t, sum, score, 1e-9, s.Ticker)
+		}
+	}
+
+	_, ok := capped.Score(item("NVDA", "", "$10", "Buy", "raised"))
+	assert.False(t, ok)
+}
+
+func TestDecay(t *testing.T) {
+	asOf := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	recent := item("NEW", "$100", "$110", "", "")
+	recent.Time = asOf.AddDate(0, 0, -1)
+	old := item("OLD", "$100", "$130", "", "")
+	old.Time = asOf.AddDate(0, 0, -180)
+
+	decay := WithDecay(Weights{Growth: 1}, 90*24*time.Hour, asOf).(Decay)
+	assert.InDelta(t, 0.25, decay.Factor(old.Time), 0.0001)
+	assert.Equal(t, 1.0, decay.Factor(asOf.Add(time.Hour)))
+
+	// Sin decaimiento gana la subida más grande; con decaimiento, la reciente
+	assert.Equal(t, "OLD", Rank([]models.StockItem{recent, old}, Weights{Growth: 1}, 1)[0].Ticker)
+	scored := Rank([]models.StockItem{recent, old}, decay, 0)
+	assert.Equal(t, "NEW", scored[0].Ticker)
+
+	Explain(scored, decay)
+	components := scored[1].Explanation
+	if assert.Len(t, components, 4) {
+		last := components[3]
+		assert.Equal(t, "decay", last.Name)
+		assert.InDelta(t, 30*0.25-30, last.Value, 0.001)
+		assert.Equal(t, "rating del 2025-01-01, 180 días antes de 2025-06-30, vida media 90 días: × 0.250", last.Rule)
+	}
+
+	assert.Equal(t, Weights{Growth: 1}, WithDecay(Weights{Growth: 1}, 0, asOf))
+}
+
+func TestParseAsOf(t *testing.T) {
+	asOf, err := ParseAsOf("2025-03-01")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 1, 23, 59, 59, 999999999, time.UTC), asOf)
+
+	asOf, err = ParseAsOf("2025-03-01T10:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), asOf)
+
+	_, err = ParseAsOf("ayer")
+	assert.Error(t, err)
+}
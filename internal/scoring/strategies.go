@@ -0,0 +1,167 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+)
+
+// Weights son los pesos de la fórmula crecimiento + rating + acción. Se
+// guardan como perfiles en la base de datos, así que tienen tags JSON y YAML.
+type Weights struct {
+	Growth    float64 `json:"growth" yaml:"growth"`                             // multiplica el % de subida del precio objetivo
+	GrowthCap float64 `json:"growth_cap,omitempty" yaml:"growth_cap,omitempty"` // tope del % de subida, en ambos sentidos (0 sin tope)
+	ScoreCap  float64 `json:"score_cap,omitempty" yaml:"score_cap,omitempty"`   // tope del score final, en ambos sentidos (0 sin tope)
+
+	// Ratings suma un bonus según rating_to (sin distinguir mayúsculas)
+	Ratings map[string]float64 `json:"ratings,omitempty" yaml:"ratings,omitempty"`
+	// Actions suma un bonus si la acción contiene la palabra. Si coinciden
+	// varias se usa la primera de ActionOrder y, entre las que no están en
+	// ActionOrder, la más larga.
+	Actions     map[string]float64 `json:"actions,omitempty" yaml:"actions,omitempty"`
+	ActionOrder []string           `json:"action_order,omitempty" yaml:"action_order,omitempty"`
+}
+
+// DefaultWeights reproduce la fórmula original de los endpoints de top,
+// incluido el orden en que se miraban las acciones
+var DefaultWeights = Weights{
+	Growth:      1,
+	Ratings:     map[string]float64{"buy": 10, "outperform": 10},
+	Actions:     map[string]float64{"raised": 5, "initiated": 2, "downgraded": -5},
+	ActionOrder: []string{"raised", "initiated", "downgraded"},
+}
+
+// ConservativeWeights da menos peso a las grandes subidas y castiga más las bajas de rating
+var ConservativeWeights = Weights{
+	Growth:      0.5,
+	GrowthCap:   50,
+	Ratings:     map[string]float64{"buy": 5, "outperform": 5},
+	Actions:     map[string]float64{"raised": 3, "downgraded": -15},
+	ActionOrder: []string{"raised", "downgraded"},
+}
+
+func init() {
+	Register(Default, DefaultWeights)
+	Register("conservative", ConservativeWeights)
+	Register("upside-only", Weights{Growth: 1})
+}
+
+// Score implementa Scorer con la fórmula ponderada. Calcula lo mismo que
+// Explain pero sin armar el desglose, que solo se pide con explain=true.
+func (w Weights) Score(s models.StockItem) (float64, bool) {
+	growth, ok := Growth(s)
+	if !ok {
+		return 0, false
+	}
+
+	score := clamp(growth, w.GrowthCap) * w.Growth
+	if bonus, ok := w.ratingBonus(s.RatingTo); ok {
+		score += bonus
+	}
+	if keyword, ok := w.matchAction(s.Action); ok {
+		score += w.Actions[keyword]
+	}
+	return clamp(score, w.ScoreCap), true
+}
+
+// Explain implementa Explainer: subida del objetivo, bonus por rating, bonus
+// por acción y, si corresponde, el ajuste por el tope del score
+func (w Weights) Explain(s models.StockItem) ([]Component, bool) {
+	growth, ok := Growth(s)
+	if !ok {
+		return nil, false
+	}
+
+	upsideRule := fmt.Sprintf("objetivo %s → %s: %+.2f%%", s.TargetFrom, s.TargetTo, growth)
+	if capped := clamp(growth, w.GrowthCap); capped != growth {
+		upsideRule += fmt.Sprintf(", topado a %+.2f%%", capped)
+		growth = capped
+	}
+	upsideRule += fmt.Sprintf(" × %g", w.Growth)
+	components := []Component{{Name: "upside", Value: growth * w.Growth, Rule: upsideRule}}
+
+	if bonus, ok := w.ratingBonus(s.RatingTo); ok {
+		components = append(components, Component{Name: "rating", Value: bonus, Rule: fmt.Sprintf("rating %q: %+g", s.RatingTo, bonus)})
+	} else {
+		components = append(components, Component{Name: "rating", Rule: fmt.Sprintf("rating %q sin bonus", s.RatingTo)})
+	}
+
+	if keyword, ok := w.matchAction(s.Action); ok {
+		bonus := w.Actions[keyword]
+		components = append(components, Component{Name: "action", Value: bonus, Rule: fmt.Sprintf("acción contiene %q: %+g", keyword, bonus)})
+	} else {
+		components = append(components, Component{Name: "action", Rule: fmt.Sprintf("acción %q sin bonus", s.Action)})
+	}
+
+	var score float64
+	for _, c := range components {
+		score += c.Value
+	}
+	if capped := clamp(score, w.ScoreCap); capped != score {
+		components = append(components, Component{
+			Name:  "score_cap",
+			Value: capped - score,
+			Rule:  fmt.Sprintf("score %.2f topado a %.2f", score, capped),
+		})
+	}
+	return components, true
+}
+
+// ratingBonus devuelve el bonus de Ratings para el rating, sin distinguir mayúsculas
+func (w Weights) ratingBonus(rating string) (float64, bool) {
+	bonus, ok := w.Ratings[strings.ToLower(strings.TrimSpace(rating))]
+	return bonus, ok
+}
+
+// matchAction busca la palabra de Actions contenida en la acción. Se prueban
+// primero las de ActionOrder en ese orden y después el resto, de la más larga
+// a la más corta y, a igual largo, alfabéticamente.
+func (w Weights) matchAction(action string) (string, bool) {
+	action = strings.ToLower(action)
+	for _, k := range w.ActionOrder {
+		if _, ok := w.Actions[k]; ok && strings.Contains(action, k) {
+			return k, true
+		}
+	}
+
+	keywords := make([]string, 0, len(w.Actions))
+	for k := range w.Actions {
+		if !slices.Contains(w.ActionOrder, k) {
+			keywords = append(keywords, k)
+		}
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if len(keywords[i]) != len(keywords[j]) {
+			return len(keywords[i]) > len(keywords[j])
+		}
+		return keywords[i] < keywords[j]
+	})
+	for _, k := range keywords {
+		if strings.Contains(action, k) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// clamp limita v a [-limit, limit]; limit 0 no limita
+func clamp(v, limit float64) float64 {
+	if limit <= 0 {
+		return v
+	}
+	return math.Max(-limit, math.Min(v, limit))
+}
+
+// Growth es el % de cambio entre el precio objetivo anterior y el nuevo.
+// Devuelve false si falta alguno de los dos o el anterior es 0.
+func Growth(s models.StockItem) (float64, bool) {
+	if s.TargetToValue == nil || s.TargetFromValue == nil || *s.TargetFromValue == 0 {
+		return 0, false
+	}
+	to, from := float64(*s.TargetToValue), float64(*s.TargetFromValue)
+	return (to - from) / from * 100, true
+}
@@ -0,0 +1,100 @@
+package scoring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeWeights lee un perfil de pesos en YAML o JSON (JSON es YAML válido).
+// Los campos desconocidos son un error, para no ignorar un peso mal escrito.
+// Las claves de ratings y actions, y las palabras de action_order, se pasan a
+// minúsculas.
+func DecodeWeights(data []byte) (Weights, error) {
+	var w Weights
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&w); err != nil {
+		if errors.Is(err, io.EOF) {
+			return w, errors.New("el perfil está vacío")
+		}
+		return w, fmt.Errorf("perfil inválido: %v", err)
+	}
+
+	var err error
+	if w.Ratings, err = lowerKeys("ratings", w.Ratings); err != nil {
+		return w, err
+	}
+	if w.Actions, err = lowerKeys("actions", w.Actions); err != nil {
+		return w, err
+	}
+	for i, k := range w.ActionOrder {
+		w.ActionOrder[i] = strings.ToLower(strings.TrimSpace(k))
+	}
+	return w, w.Validate()
+}
+
+// Validate comprueba que los pesos sean números finitos, los topes no
+// negativos y que action_order solo nombre palabras de actions, sin repetir
+func (w Weights) Validate() error {
+	var problems []string
+	check := func(name string, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			problems = append(problems, name+" debe ser un número finito")
+		}
+	}
+	check("growth", w.Growth)
+	check("growth_cap", w.GrowthCap)
+	check("score_cap", w.ScoreCap)
+	if w.GrowthCap < 0 {
+		problems = append(problems, "growth_cap no puede ser negativo")
+	}
+	if w.ScoreCap < 0 {
+		problems = append(problems, "score_cap no puede ser negativo")
+	}
+	for k, v := range w.Ratings {
+		check("ratings."+k, v)
+	}
+	for k, v := range w.Actions {
+		check("actions."+k, v)
+		if strings.TrimSpace(k) == "" {
+			problems = append(problems, "actions no puede tener una palabra vacía")
+		}
+	}
+	for i, k := range w.ActionOrder {
+		if _, ok := w.Actions[k]; !ok {
+			problems = append(problems, fmt.Sprintf("action_order.%s no está en actions", k))
+		} else if slices.Index(w.ActionOrder, k) != i {
+			problems = append(problems, fmt.Sprintf("action_order.%s está repetido", k))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("perfil inválido: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// lowerKeys pasa las claves a minúsculas y falla si dos quedan iguales
+func lowerKeys(field string, m map[string]float64) (map[string]float64, error) {
+	if m == nil {
+		return nil, nil
+	}
+	out := make(map[string]float64, len(m))
+	for k, v := range m {
+		key := strings.ToLower(strings.TrimSpace(k))
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("perfil inválido: %s.%s está repetido", field, key)
+		}
+		out[key] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PageArchive guarda comprimida cada respuesta original de la API en
+// <dir>/<run_id>/page-000001.json.gz para auditoría y replay
+type PageArchive struct {
+	dir string
+}
+
+// NewPageArchive devuelve nil si dir está vacío, es decir, si el archivo está desactivado
+func NewPageArchive(dir string) *PageArchive {
+	if dir == "" {
+		return nil
+	}
+	return &PageArchive{dir: dir}
+}
+
+// Save escribe la página de forma atómica, reemplazando la anterior si se reintenta
+func (a *PageArchive) Save(runID string, page int, raw []byte) error {
+	runDir := filepath.Join(a.dir, runID)
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return fmt.Errorf("error creando directorio de archivo: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(runDir, ".page-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error archivando página %d: %v", page, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if _, err := gz.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error archivando página %d: %v", page, err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error archivando página %d: %v", page, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error archivando página %d: %v", page, err)
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(runDir, pageFileName(page)))
+}
+
+// Pages devuelve los números de página archivados de una ejecución, en orden
+func (a *PageArchive) Pages(runID string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(a.dir, runID))
+	if err != nil {
+		return nil, fmt.Errorf("no hay páginas archivadas para %s: %v", runID, err)
+	}
+
+	var pages []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "page-") || !strings.HasSuffix(name, ".json.gz") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "page-"), ".json.gz"))
+		if err == nil {
+			pages = append(pages, n)
+		}
+	}
+	sort.Ints(pages)
+	return pages, nil
+}
+
+// Load lee y descomprime una página archivada
+func (a *PageArchive) Load(runID string, page int) ([]byte, error) {
+	f, err := os.Open(filepath.Join(a.dir, runID, pageFileName(page)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("página %d corrupta: %v", page, err)
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+func pageFileName(page int) string {
+	return fmt.Sprintf("page-%06d.json.gz", page)
+}
+
+// ArchiveSource relee las páginas archivadas de una ejecución sin llamar a la API.
+// El token es el índice de la siguiente página en la lista archivada.
+type ArchiveSource struct {
+	archive *PageArchive
+	runID   string
+	pages   []int
+}
+
+func NewArchiveSource(archive *PageArchive, runID string) (*ArchiveSource, error) {
+	pages, err := archive.Pages(runID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no hay páginas archivadas para %s", runID)
+	}
+	return &ArchiveSource{archive: archive, runID: runID, pages: pages}, nil
+}
+
+func (s *ArchiveSource) FetchPage(ctx context.Context, token string) (Page, error) {
+	idx := 0
+	if token != "" {
+		var err error
+		idx, err = strconv.Atoi(token)
+		if err != nil || idx < 0 || idx >= len(s.pages) {
+			return Page{}, fmt.Errorf("token de página inválido %q", token)
+		}
+	}
+
+	raw, err := s.archive.Load(s.runID, s.pages[idx])
+	if err != nil {
+		return Page{}, err
+	}
+
+	var apiResp APIResponse
+	if err := json.Unmarshal(raw, &apiResp); err != nil {
+		return Page{}, fmt.Errorf("página archivada %d inválida: %v", s.pages[idx], err)
+	}
+
+	page := Page{Items: apiResp.Items}
+	if idx+1 < len(s.pages) {
+		page.NextPage = strconv.Itoa(idx + 1)
+	}
+	return page, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageArchive_SaveAndLoad(t *testing.T) {
+	archive := NewPageArchive(t.TempDir())
+
+	assert.NoError(t, archive.Save("run-1", 2, []byte(`{"items":[],"next_page":""}`)))
+	assert.NoError(t, archive.Save("run-1", 1, []byte(`{"items":[],"next_page":"p2"}`)))
+
+	pages, err := archive.Pages("run-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, pages)
+
+	raw, err := archive.Load("run-1", 1)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"items":[],"next_page":"p2"}`, string(raw))
+
+	_, err = NewArchiveSource(archive, "missing")
+	assert.Error(t, err)
+}
+
+func TestSyncStocks_ReplayFromArchive(t *testing.T) {
+	db := setupTestDB(t)
+	api := fakeAPI(t, map[string]APIResponse{
+		"":   page("p2", item("AAPL", "2025-05-02T00:00:00Z")),
+		"p2": page("", item("GOOG", "2025-05-01T00:00:00Z")),
+	})
+	ctx := context.Background()
+
+	archive := NewPageArchive(t.TempDir())
+	first, err := SyncStocks(ctx, db, SyncOptions{Mode: SyncFull, Trigger: TriggerManual, Source: api, Archive: archive})
+	assert.NoError(t, err)
+
+	// Reconstruir la tabla sin la API
+	_, err = db.NewDelete().Model((*models.StockItem)(nil)).Where("1 = 1").Exec(ctx)
+	assert.NoError(t, err)
+
+	source, err := NewArchiveSource(archive, first.RunID)
+	assert.NoError(t, err)
+
+	result, err := SyncStocks(ctx, db, SyncOptions{Mode: SyncFull, Trigger: TriggerReplay, Source: source, Archive: archive})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 2, result.Inserted)
+
+	// La reproducción no vuelve a archivar páginas
+	_, err = archive.Pages(result.RunID)
+	assert.Error(t, err)
+}
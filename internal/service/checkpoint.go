@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// newCheckpoint registra el inicio de una nueva ejecución
+func newCheckpoint(ctx context.Context, db bun.IDB, runID string, mode SyncMode, since time.Time) (*models.IngestionCheckpoint, error) {
+	if runID == "" {
+		runID = uuid.NewString()
+	}
+	cp := &models.IngestionCheckpoint{
+		RunID:     runID,
+		Mode:      mode.String(),
+		Since:     since,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if _, err := db.NewInsert().Model(cp).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("error creando checkpoint: %v", err)
+	}
+	return cp, nil
+}
+
+// LatestUnfinishedCheckpoint devuelve la ejecución sin terminar más reciente, o nil si no hay
+func LatestUnfinishedCheckpoint(ctx context.Context, db bun.IDB) (*models.IngestionCheckpoint, error) {
+	cp := new(models.IngestionCheckpoint)
+	err := db.NewSelect().
+		Model(cp).
+		Where("completed = ?", false).
+		Order("updated_at DESC").
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error buscando checkpoints pendientes: %v", err)
+	}
+	return cp, nil
+}
+
+// saveCheckpoint actualiza el progreso; se llama dentro de la transacción de la página
+func saveCheckpoint(ctx context.Context, db bun.IDB, cp *models.IngestionCheckpoint) error {
+	cp.UpdatedAt = time.Now().UTC()
+	_, err := db.NewUpdate().
+		Model(cp).
+		Column("next_page", "page", "rows_written", "completed", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error guardando checkpoint: %v", err)
+	}
+	return nil
+}
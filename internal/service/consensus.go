@@ -0,0 +1,219 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/scoring"
+)
+
+// DefaultConsensusWindow es la ventana por defecto para contar subidas y bajadas de rating
+const DefaultConsensusWindow = 90 * 24 * time.Hour
+
+// DefaultConsensusMaxAge es la antigüedad máxima por defecto del último rating
+// de una corredora para que cuente en el consenso
+const DefaultConsensusMaxAge = 365 * 24 * time.Hour
+
+// Consensus resume la opinión vigente de las corredoras sobre un ticker: el
+// último rating de cada una, estadísticas del precio objetivo, cuántas
+// recomiendan comprar, mantener o vender, y el score de consenso
+type Consensus struct {
+	Ticker     string            `json:"ticker"`
+	Company    string            `json:"company"`
+	Brokerages int               `json:"brokerages"`
+	Target     *TargetStats      `json:"target"` // nil si ninguna corredora publicó un precio válido
+	Buy        int               `json:"buy"`
+	Hold       int               `json:"hold"`
+	Sell       int               `json:"sell"`
+	Other      int               `json:"other"` // ratings fuera del vocabulario conocido
+	Upgrades   int               `json:"upgrades"`
+	Downgrades int               `json:"downgrades"`
+	NetUpgrade int               `json:"net_upgrades"`
+	Score      *float64          `json:"score"` // mediana de los scores; nil si ninguno se pudo puntuar
+	Ratings    []BrokerageRating `json:"ratings"`
+}
+
+// TargetStats son las estadísticas de los precios objetivo vigentes. Solo se
+// usan los de la moneda más frecuente para no mezclar monedas.
+type TargetStats struct {
+	Currency string  `json:"currency"`
+	Count    int     `json:"count"`
+	Mean     float64 `json:"mean"`
+	Median   float64 `json:"median"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+}
+
+// BrokerageRating es el último rating de una corredora sobre el ticker
+type BrokerageRating struct {
+	Brokerage string    `json:"brokerage"`
+	RatingTo  string    `json:"rating_to"`
+	Class     string    `json:"class,omitempty"` // buy, hold o sell
+	Action    string    `json:"action"`
+	TargetTo  string    `json:"target_to"`
+	Time      time.Time `json:"time"`
+	Score     *float64  `json:"score"`
+}
+
+// ConsensusOptions controla cómo se arma el consenso
+type ConsensusOptions struct {
+	Scorer scoring.Scorer // puntúa el último rating de cada corredora
+	AsOf   time.Time      // fin de la ventana de subidas y bajadas
+	Window time.Duration  // 0 usa DefaultConsensusWindow
+	// MaxAge descarta a las corredoras cuyo último rating es anterior a
+	// AsOf-MaxAge: un rating de hace años no es una opinión vigente aunque
+	// nadie lo haya reemplazado. 0 no descarta ninguno.
+	MaxAge time.Duration
+}
+
+// BuildConsensus agrupa los ratings por ticker y devuelve un consenso por
+// cada uno, ordenados por score de mayor a menor (los sin score al final).
+// Tomar el último rating de cada corredora y la mediana de sus scores evita
+// que un ticker aparezca varias veces o que una sola corredora lo domine.
+func BuildConsensus(stocks []models.StockItem, opts ConsensusOptions) []Consensus {
+	if opts.Window <= 0 {
+		opts.Window = DefaultConsensusWindow
+	}
+
+	byTicker := make(map[string][]models.StockItem)
+	for _, s := range stocks {
+		byTicker[s.Ticker] = append(byTicker[s.Ticker], s)
+	}
+
+	result := make([]Consensus, 0, len(byTicker))
+	for ticker, ratings := range byTicker {
+		result = append(result, tickerConsensus(ticker, ratings, opts))
+	}
+
+	slices.SortFunc(result, func(a, b Consensus) int {
+		switch {
+		case a.Score != nil && b.Score == nil:
+			return -1
+		case a.Score == nil && b.Score != nil:
+			return 1
+		case a.Score != nil && *a.Score != *b.Score:
+			return cmp.Compare(*b.Score, *a.Score)
+		}
+		return strings.Compare(a.Ticker, b.Ticker)
+	})
+	return result
+}
+
+func tickerConsensus(ticker string, ratings []models.StockItem, opts ConsensusOptions) Consensus {
+	c := Consensus{Ticker: ticker}
+	windowStart := opts.AsOf.Add(-opts.Window)
+	var staleBefore time.Time
+	if opts.MaxAge > 0 {
+		staleBefore = opts.AsOf.Add(-opts.MaxAge)
+	}
+
+	// El más reciente primero; a igual fecha, el último insertado
+	slices.SortFunc(ratings, func(a, b models.StockItem) int {
+		if n := b.Time.Compare(a.Time); n != 0 {
+			return n
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	latest := make(map[string]bool)
+	var scores []float64
+	targets := make(map[string][]float64)
+	for _, r := range ratings {
+		if c.Company == "" {
+			c.Company = r.Company
+		}
+
+		action := strings.ToLower(r.Action)
+		if !r.Time.Before(windowStart) && !r.Time.After(opts.AsOf) {
+			if strings.Contains(action, "upgraded") {
+				c.Upgrades++
+			} else if strings.Contains(action, "downgraded") {
+				c.Downgrades++
+			}
+		}
+
+		brokerage := strings.ToLower(strings.TrimSpace(r.Brokerage))
+		if latest[brokerage] || r.Time.Before(staleBefore) {
+			continue
+		}
+		latest[brokerage] = true
+
+		entry := BrokerageRating{
+			Brokerage: r.Brokerage,
+			RatingTo:  r.RatingTo,
+			Class:     dto.RatingClass(r.RatingTo),
+			Action:    r.Action,
+			TargetTo:  r.TargetTo,
+			Time:      r.Time.UTC(),
+		}
+		switch entry.Class {
+		case dto.RatingBuy:
+			c.Buy++
+		case dto.RatingHold:
+			c.Hold++
+		case dto.RatingSell:
+			c.Sell++
+		default:
+			c.Other++
+		}
+		if score, ok := opts.Scorer.Score(r); ok {
+			entry.Score = &score
+			scores = append(scores, score)
+		}
+		if r.TargetToValue != nil {
+			targets[r.Currency] = append(targets[r.Currency], float64(*r.TargetToValue))
+		}
+		c.Ratings = append(c.Ratings, entry)
+	}
+
+	c.Brokerages = len(c.Ratings)
+	c.NetUpgrade = c.Upgrades - c.Downgrades
+	if len(scores) > 0 {
+		score := median(scores)
+		c.Score = &score
+	}
+	c.Target = targetStats(targets)
+	return c
+}
+
+// targetStats calcula las estadísticas con la moneda que más precios tiene
+func targetStats(byCurrency map[string][]float64) *TargetStats {
+	var currency string
+	for cur, values := range byCurrency {
+		if best := byCurrency[currency]; len(values) > len(best) || (len(values) == len(best) && cur < currency) {
+			currency = cur
+		}
+	}
+	values := byCurrency[currency]
+	if len(values) == 0 {
+		return nil
+	}
+
+	stats := &TargetStats{
+		Currency: currency,
+		Count:    len(values),
+		Median:   median(values),
+		High:     slices.Max(values),
+		Low:      slices.Min(values),
+	}
+	for _, v := range values {
+		stats.Mean += v
+	}
+	stats.Mean /= float64(len(values))
+	return stats
+}
+
+// median devuelve la mediana sin modificar values
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/scoring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildConsensus(t *testing.T) {
+	asOf := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	rating := func(ticker, brokerage, ratingTo, action, target string, daysAgo int) models.StockItem {
+		s := models.StockItem{
+			Ticker:     ticker,
+			Company:    ticker + " Inc.",
+			Brokerage:  brokerage,
+			RatingTo:   ratingTo,
+			Action:     action,
+			TargetFrom: "$100",
+			TargetTo:   target,
+			Time:       asOf.AddDate(0, 0, -daysAgo),
+		}
+		s.NormalizeTargets()
+		return s
+	}
+
+	stocks := []models.StockItem{
+		// Goldman publicó dos veces: solo cuenta la última
+		rating("AAPL", "Goldman", "Sell", "downgraded by", "$90", 200),
+		rating("AAPL", "Goldman", "Buy", "upgraded by", "$150", 10),
+		rating("AAPL", "Morgan", "Neutral", "target raised by", "$120", 20),
+		rating("AAPL", "UBS", "Buy", "upgraded by", "$130", 30),
+		rating("AAPL", "Citi", "Maybe", "reiterated by", "N/A", 5),
+		rating("GOOG", "Goldman", "Underperform", "downgraded by", "$80", 1),
+	}
+
+	scorer, err := scoring.Get(scoring.Default)
+	assert.NoError(t, err)
+	result := BuildConsensus(stocks, ConsensusOptions{Scorer: scorer, AsOf: asOf})
+	if !assert.Len(t, result, 2) {
+		return
+	}
+
+	aapl := result[0]
+	assert.Equal(t, "AAPL", aapl.Ticker)
+	assert.Equal(t, "AAPL Inc.", aapl.Company)
+	assert.Equal(t, 4, aapl.Brokerages)
+	assert.Equal(t, "Citi", aapl.Ratings[0].Brokerage)
+	assert.Equal(t, 2, aapl.Buy)
+	assert.Equal(t, 1, aapl.Hold)
+	assert.Equal(t, 0, aapl.Sell)
+	assert.Equal(t, 1, aapl.Other)
+
+	// El downgrade de hace 200 días queda fuera de la ventana de 90
+	assert.Equal(t, 2, aapl.Upgrades)
+	assert.Equal(t, 0, aapl.Downgrades)
+	assert.Equal(t, 2, aapl.NetUpgrade)
+
+	if assert.NotNil(t, aapl.Target) {
+		assert.Equal(t, "USD", aapl.Target.Currency)
+		assert.Equal(t, 3, aapl.Target.Count)
+		assert.InDelta(t, 133.33, aapl.Target.Mean, 0.01)
+		assert.Equal(t, 130.0, aapl.Target.Median)
+		assert.Equal(t, 150.0, aapl.Target.High)
+		assert.Equal(t, 120.0, aapl.Target.Low)
+	}
+
+	// Scores por corredora: Goldman 50+10, Morgan 20, UBS 30+10; Citi sin precio no puntúa
+	assert.Nil(t, aapl.Ratings[0].Score)
+	if assert.NotNil(t, aapl.Score) {
+		assert.InDelta(t, 40, *aapl.Score, 0.001)
+	}
+
+	goog := result[1]
+	assert.Equal(t, 1, goog.Sell)
+	assert.Equal(t, -1, goog.NetUpgrade)
+
+	// Una ventana más larga sí cuenta el downgrade antiguo
+	result = BuildConsensus(stocks, ConsensusOptions{Scorer: scorer, AsOf: asOf, Window: 365 * 24 * time.Hour})
+	assert.Equal(t, 1, result[0].Downgrades)
+	assert.Equal(t, 1, result[0].NetUpgrade)
+
+	// Con una antigüedad máxima de 15 días Morgan y UBS dejan de contar
+	result = BuildConsensus(stocks, ConsensusOptions{Scorer: scorer, AsOf: asOf, MaxAge: 15 * 24 * time.Hour})
+	aapl = result[0]
+	assert.Equal(t, 2, aapl.Brokerages)
+	assert.Equal(t, 1, aapl.Buy)
+	assert.Equal(t, 0, aapl.Hold)
+	assert.Equal(t, 1, aapl.Other)
+	if assert.NotNil(t, aapl.Target) {
+		assert.Equal(t, 1, aapl.Target.Count)
+	}
+	// Las subidas de la ventana se siguen contando aunque la corredora no esté vigente
+	assert.Equal(t, 2, aapl.Upgrades)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/uptrace/bun"
+)
+
+// Clasificación de cada item en un dry-run
+const (
+	DiffNew      = "new"      // no existe en stock_items
+	DiffChanged  = "changed"  // existe, pero con datos distintos
+	DiffPresent  = "present"  // existe y es idéntico
+	DiffRejected = "rejected" // no pasó la validación
+)
+
+// DiffEntry es una línea del archivo de diff NDJSON
+type DiffEntry struct {
+	Kind    string            `json:"kind"`
+	Page    int               `json:"page"`
+	Item    *dto.StockItem    `json:"item,omitempty"`
+	Changes map[string][2]any `json:"changes,omitempty"` // campo -> [actual, nuevo]
+	Reason  string            `json:"reason,omitempty"`
+	Raw     json.RawMessage   `json:"raw,omitempty"` // JSON original de los rechazados
+}
+
+// DryRunResult resume lo que haría una sincronización
+type DryRunResult struct {
+	Pages    int
+	New      int
+	Changed  int
+	Present  int
+	Rejected int
+}
+
+// DryRunStocks recorre la fuente igual que SyncStocks pero sin escribir nada:
+// compara cada item con stock_items por la clave natural y lo clasifica como
+// nuevo, cambiado o ya presente. Si diff no es nil se escribe una línea NDJSON
+// por cada item nuevo, cambiado o rechazado.
+func DryRunStocks(ctx context.Context, db *bun.DB, opts SyncOptions, diff io.Writer) (DryRunResult, error) {
+	var result DryRunResult
+
+	if opts.Source == nil {
+		return result, errNoSource
+	}
+
+	var since time.Time
+	if opts.Mode == SyncIncremental {
+		var err error
+		if since, err = LatestStockTime(ctx, db); err != nil {
+			return result, err
+		}
+	}
+
+	var enc *json.Encoder
+	if diff != nil {
+		enc = json.NewEncoder(diff)
+	}
+	emit := func(entry DiffEntry) error {
+		if enc == nil {
+			return nil
+		}
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("error escribiendo el diff: %v", err)
+		}
+		return nil
+	}
+
+	// Items ya vistos en esta misma ejecución, por si la fuente los repite
+	seen := make(map[string]bool)
+
+	token := ""
+	for num := 1; ; num++ {
+		fmt.Printf("📦 Descargando página %d...\n", num)
+		page, err := opts.Source.FetchPage(ctx, token)
+		if err != nil {
+			return result, err
+		}
+		result.Pages++
+
+		valid, rejected := decodeItems(page.Items, "", num)
+		items, reachedKnown := newerThan(valid, since)
+
+		for _, r := range rejected {
+			result.Rejected++
+			if err := emit(DiffEntry{Kind: DiffRejected, Page: num, Reason: r.Reason, Raw: r.Raw}); err != nil {
+				return result, err
+			}
+		}
+
+		stored, err := findStored(ctx, db, items)
+		if err != nil {
+			return result, err
+		}
+
+		for _, m := range items {
+			key := naturalKey(m)
+			current, exists := stored[key]
+			item := dto.FromModel(m)
+
+			switch {
+			case seen[key]:
+				result.Present++
+			case !exists:
+				result.New++
+				if err := emit(DiffEntry{Kind: DiffNew, Page: num, Item: &item}); err != nil {
+					return result, err
+				}
+			case current.Company != m.Company:
+				result.Changed++
+				changes := map[string][2]any{"company": {current.Company, m.Company}}
+				if err := emit(DiffEntry{Kind: DiffChanged, Page: num, Item: &item, Changes: changes}); err != nil {
+					return result, err
+				}
+			default:
+				result.Present++
+			}
+			seen[key] = true
+		}
+
+		if reachedKnown || page.NextPage == "" {
+			break
+		}
+		token = page.NextPage
+	}
+
+	fmt.Printf("🧪 Dry-run completado: %d páginas, %d items nuevos, %d cambiados, %d ya existentes, %d rechazados. No se escribió nada.\n",
+		result.Pages, result.New, result.Changed, result.Present, result.Rejected)
+	return result, nil
+}
+
+// findStored busca en stock_items los items de una página y los devuelve por clave natural
+func findStored(ctx context.Context, db *bun.DB, items []models.StockItem) (map[string]models.StockItem, error) {
+	stored := make(map[string]models.StockItem)
+	if len(items) == 0 {
+		return stored, nil
+	}
+
+	tickers := make([]string, 0, len(items))
+	from, to := items[0].Time, items[0].Time
+	for _, m := range items {
+		tickers = append(tickers, m.Ticker)
+		if m.Time.Before(from) {
+			from = m.Time
+		}
+		if m.Time.After(to) {
+			to = m.Time
+		}
+	}
+
+	var rows []models.StockItem
+	err := db.NewSelect().
+		Model(&rows).
+		Where("ticker IN (?)", bun.In(tickers)).
+		Where("time BETWEEN ? AND ?", from, to).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando items existentes: %v", err)
+	}
+
+	for _, row := range rows {
+		stored[naturalKey(row)] = row
+	}
+	return stored, nil
+}
+
+// naturalKey arma la clave natural de un item (ver models.StockItemNaturalKey),
+// con time a la precisión con la que se almacena
+func naturalKey(m models.StockItem) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s",
+		m.Ticker, m.Brokerage, m.Time.UTC().Truncate(models.TimePrecision).Format(time.RFC3339Nano), m.Action,
+		m.RatingFrom, m.RatingTo, m.TargetFrom, m.TargetTo)
+}
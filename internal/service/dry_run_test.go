@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunStocks_ClassifiesWithoutWriting(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	present := item("AAPL", "2025-05-01T00:00:00Z")
+	changed := item("GOOG", "2025-05-02T00:00:00Z")
+	stored := []models.StockItem{model(t, present), model(t, changed)}
+	_, _, err := UpsertStocks(ctx, db, stored)
+	assert.NoError(t, err)
+
+	changed.Company = "Alphabet Inc."
+	invalid := item("MSFT", "2025-05-03T00:00:00Z")
+	invalid.RatingTo = ""
+	source := NewFixtureSource(2, item("TSLA", "2025-05-04T00:00:00Z"), changed, present, invalid)
+
+	var diff bytes.Buffer
+	result, err := DryRunStocks(ctx, db, SyncOptions{Mode: SyncFull, Source: source}, &diff)
+	assert.NoError(t, err)
+	assert.Equal(t, DryRunResult{Pages: 2, New: 1, Changed: 1, Present: 1, Rejected: 1}, result)
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	lines := strings.Split(strings.TrimSpace(diff.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	kinds := map[string]DiffEntry{}
+	for _, line := range lines {
+		var entry DiffEntry
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		kinds[entry.Kind] = entry
+	}
+	assert.Equal(t, "TSLA", kinds[DiffNew].Item.Ticker)
+	assert.Equal(t, [2]any{"GOOG Inc.", "Alphabet Inc."}, kinds[DiffChanged].Changes["company"])
+	assert.NotEmpty(t, kinds[DiffRejected].Reason)
+}
+
+func TestDryRunStocks_NanosecondTimes(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	// La API manda nanosegundos pero la base de datos guarda microsegundos
+	precise := item("AAPL", "2025-05-01T10:00:00.123456789Z")
+	_, _, err := UpsertStocks(ctx, db, []models.StockItem{model(t, precise)})
+	assert.NoError(t, err)
+
+	source := NewFixtureSource(10, precise)
+	result, err := DryRunStocks(ctx, db, SyncOptions{Mode: SyncFull, Source: source}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DryRunResult{Pages: 1, Present: 1}, result)
+
+	// En modo incremental el último item almacenado se reconoce como existente
+	synced, err := SyncStocks(ctx, db, SyncOptions{Mode: SyncIncremental, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, synced.Inserted)
+	assert.Equal(t, 1, synced.Skipped)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/uptrace/bun"
+)
+
+// Formatos de exportación
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// ListStocks devuelve todos los ratings, o solo los de una corredora si se
+// indica. Con asOf distinto de cero solo incluye los publicados hasta esa fecha.
+func ListStocks(ctx context.Context, db *bun.DB, brokerage string, asOf time.Time) ([]models.StockItem, error) {
+	var stocks []models.StockItem
+	q := db.NewSelect().Model(&stocks).Order("time DESC", "id")
+	if brokerage != "" {
+		q = q.Where("LOWER(brokerage) = LOWER(?)", brokerage)
+	}
+	if !asOf.IsZero() {
+		q = q.Where("time <= ?", asOf)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("error cargando los ratings: %v", err)
+	}
+	return stocks, nil
+}
+
+// ExportStocks escribe todos los ratings en CSV o JSON con los mismos campos
+// que la API externa, de modo que se pueden volver a cargar con la importación
+// o con sync -file
+func ExportStocks(ctx context.Context, db *bun.DB, w io.Writer, format string) (int, error) {
+	if format != ExportCSV && format != ExportJSON {
+		return 0, fmt.Errorf("formato no soportado %q: usa csv o json", format)
+	}
+
+	stocks, err := ListStocks(ctx, db, "", time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]dto.StockItem, 0, len(stocks))
+	for _, s := range stocks {
+		items = append(items, dto.FromModel(s))
+	}
+
+	if format == ExportJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(items); err != nil {
+			return 0, fmt.Errorf("error escribiendo JSON: %v", err)
+		}
+		return len(items), nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(importFields); err != nil {
+		return 0, fmt.Errorf("error escribiendo CSV: %v", err)
+	}
+	for _, it := range items {
+		record := []string{
+			it.Ticker, it.TargetFrom, it.TargetTo, it.Company, it.Action,
+			it.Brokerage, it.RatingFrom, it.RatingTo, it.Time,
+		}
+		if err := writer.Write(record); err != nil {
+			return 0, fmt.Errorf("error escribiendo CSV: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, fmt.Errorf("error escribiendo CSV: %v", err)
+	}
+	return len(items), nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportStocks_RoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, _, err := UpsertStocks(ctx, db, []models.StockItem{
+		model(t, item("AAPL", "2025-05-02T00:00:00Z")),
+		model(t, item("GOOG", "2025-05-01T00:00:00Z")),
+	})
+	assert.NoError(t, err)
+
+	var csvOut bytes.Buffer
+	n, err := ExportStocks(ctx, db, &csvOut, ExportCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// El CSV exportado se puede volver a importar tal cual
+	rows, err := ParseImportFile(&csvOut, ImportCSV, nil, "")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, item("AAPL", "2025-05-02T00:00:00Z"), rows[0].Item)
+	}
+
+	var jsonOut bytes.Buffer
+	_, err = ExportStocks(ctx, db, &jsonOut, ExportJSON)
+	assert.NoError(t, err)
+	var items []dto.StockItem
+	assert.NoError(t, json.Unmarshal(jsonOut.Bytes(), &items))
+	assert.Len(t, items, 2)
+
+	_, err = ExportStocks(ctx, db, &jsonOut, "xml")
+	assert.Error(t, err)
+}
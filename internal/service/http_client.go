@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+)
+
+// RetryPolicy controla los reintentos al pedir una página a la API externa
+type RetryPolicy struct {
+	MaxAttempts int           // intentos máximos por página, incluido el primero
+	BaseDelay   time.Duration // espera antes del primer reintento
+	MaxDelay    time.Duration // tope de la espera exponencial y de Retry-After
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// upstreamClient pide páginas a la API externa con timeout y reintentos
+type upstreamClient struct {
+	http       *http.Client
+	retry      RetryPolicy
+	authHeader string
+}
+
+// newUpstreamClient crea el cliente con el timeout y los intentos configurados
+func newUpstreamClient(cfg config.Upstream) *upstreamClient {
+	retry := DefaultRetryPolicy
+	if cfg.MaxAttempts > 0 {
+		retry.MaxAttempts = cfg.MaxAttempts
+	}
+
+	return &upstreamClient{
+		http:       &http.Client{Timeout: cfg.Timeout},
+		retry:      retry,
+		authHeader: cfg.AuthHeader,
+	}
+}
+
+// fetchPage descarga y decodifica una página, reintentando ante errores de red,
+// 429 y 5xx hasta agotar los intentos de la política. También devuelve el body
+// original para archivarlo.
+func (c *upstreamClient) fetchPage(ctx context.Context, url string) (APIResponse, []byte, error) {
+	var lastErr error
+
+	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
+		apiResp, body, retryAfter, err := c.fetchOnce(ctx, url)
+		if err == nil {
+			return apiResp, body, nil
+		}
+		lastErr = err
+
+		if retryAfter < 0 || ctx.Err() != nil || attempt == c.retry.MaxAttempts {
+			break
+		}
+
+		delay := c.retry.delay(attempt, retryAfter)
+		log.Printf("🔁 Intento %d/%d fallido (%v), reintentando en %s...", attempt, c.retry.MaxAttempts, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return APIResponse{}, nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return APIResponse{}, nil, lastErr
+}
+
+// fetchOnce hace un único intento. retryAfter es negativo si el error no se
+// debe reintentar, cero para usar el backoff, o la espera pedida por el servidor.
+func (c *upstreamClient) fetchOnce(ctx context.Context, url string) (apiResp APIResponse, body []byte, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return apiResp, nil, -1, fmt.Errorf("error creando request: %v", err)
+	}
+
+	req.Header.Set("Authorization", c.authHeader)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return apiResp, nil, 0, fmt.Errorf("error haciendo request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("respuesta no exitosa: %d\n%s", resp.StatusCode, string(body))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return apiResp, nil, parseRetryAfter(resp.Header.Get("Retry-After")), err
+		}
+		return apiResp, nil, -1, err
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return apiResp, nil, 0, fmt.Errorf("error leyendo respuesta: %v", err)
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return apiResp, nil, -1, fmt.Errorf("error decodificando JSON: %v", err)
+	}
+	return apiResp, body, 0, nil
+}
+
+// backoff calcula la espera exponencial con jitter para el intento dado
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Mitad fija y mitad aleatoria para que los clientes no reintenten a la vez
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// delay elige la espera antes del siguiente intento: la pedida por el
+// servidor en Retry-After, con MaxDelay como tope para que un valor enorme no
+// detenga la sincronización, o si no el backoff exponencial
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, p.MaxDelay)
+	}
+	return p.backoff(attempt)
+}
+
+// parseRetryAfter interpreta el header Retry-After en segundos o como fecha HTTP
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
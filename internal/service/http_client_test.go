@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testClient() *upstreamClient {
+	return &upstreamClient{
+		http:       &http.Client{Timeout: time.Second},
+		retry:      RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		authHeader: "Bearer test",
+	}
+}
+
+func TestFetchPage_RetriesOnServerErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_ = json.NewEncoder(w).Encode(page("", item("AAPL", "2025-05-02T00:00:00Z")))
+		}
+	}))
+	defer server.Close()
+
+	resp, body, err := testClient().fetchPage(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, resp.Items, 1)
+	assert.Contains(t, string(body), "AAPL")
+}
+
+func TestFetchPage_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, _, err := testClient().fetchPage(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestFetchPage_DoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, _, err := testClient().fetchPage(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFetchPage_CapsRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(page("", item("AAPL", "2025-05-02T00:00:00Z")))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, err := testClient().fetchPage(ctx, server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("nunca"))
+
+	// Un Retry-After de un día o una fecha lejana se limitan a MaxDelay
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	assert.Equal(t, 30*time.Second, policy.delay(1, parseRetryAfter("86400")))
+	assert.Equal(t, 30*time.Second, policy.delay(1, parseRetryAfter(time.Now().AddDate(1, 0, 0).UTC().Format(http.TimeFormat))))
+	assert.Equal(t, 2*time.Second, policy.delay(1, parseRetryAfter("2")))
+}
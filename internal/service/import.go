@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/uptrace/bun"
+	"github.com/xuri/excelize/v2"
+)
+
+// Formatos de archivo aceptados por la importación
+const (
+	ImportCSV  = "csv"
+	ImportXLSX = "xlsx"
+)
+
+// ErrInvalidImport indica que alguna fila no pasó la validación y no se importó nada
+var ErrInvalidImport = errors.New("el archivo tiene filas inválidas")
+
+// importFields son los campos de dto.StockItem que se pueden mapear, por su nombre JSON
+var importFields = []string{
+	"ticker", "target_from", "target_to", "company", "action",
+	"brokerage", "rating_from", "rating_to", "time",
+}
+
+// ColumnMapping indica, para cada campo (ticker, target_to, ...), el encabezado
+// de la columna que lo contiene. Los campos sin mapear se buscan por su propio nombre.
+type ColumnMapping map[string]string
+
+// ImportRow es una fila del archivo ya asociada a los campos del item
+type ImportRow struct {
+	Row  int // número de fila en el archivo, contando el encabezado como 1
+	Item dto.StockItem
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportResult struct {
+	Rows     int              `json:"rows"`
+	Inserted int              `json:"inserted"`
+	Updated  int              `json:"updated"`
+	Skipped  int              `json:"skipped"`
+	Errors   []ImportRowError `json:"errors,omitempty"`
+}
+
+// ParseImportFile lee un CSV o XLSX con encabezados y arma las filas según el mapeo.
+// En XLSX se usa la hoja indicada o la primera.
+func ParseImportFile(r io.Reader, format string, mapping ColumnMapping, sheet string) ([]ImportRow, error) {
+	var records [][]string
+	var err error
+
+	switch format {
+	case ImportCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err = reader.ReadAll()
+	case ImportXLSX:
+		records, err = readXLSX(r, sheet)
+	default:
+		return nil, fmt.Errorf("formato no soportado %q: usa csv o xlsx", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el archivo: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+
+	columns, err := resolveColumns(records[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ImportRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		get := func(field string) string {
+			idx, ok := columns[field]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+		rows = append(rows, ImportRow{
+			Row: i + 2,
+			Item: dto.StockItem{
+				Ticker:     get("ticker"),
+				TargetFrom: get("target_from"),
+				TargetTo:   get("target_to"),
+				Company:    get("company"),
+				Action:     get("action"),
+				Brokerage:  get("brokerage"),
+				RatingFrom: get("rating_from"),
+				RatingTo:   get("rating_to"),
+				Time:       get("time"),
+			},
+		})
+	}
+	return rows, nil
+}
+
+// ImportStocks valida todas las filas con las mismas reglas que el loader y, si
+// todas son válidas, las inserta en una sola transacción. Si alguna falla no se
+// inserta nada y se devuelve ErrInvalidImport con el detalle por fila.
+func ImportStocks(ctx context.Context, db *bun.DB, rows []ImportRow) (ImportResult, error) {
+	result := ImportResult{Rows: len(rows)}
+
+	items := make([]models.StockItem, 0, len(rows))
+	for _, row := range rows {
+		m, err := row.Item.ToModel()
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{Row: row.Row, Error: err.Error()})
+			continue
+		}
+		items = append(items, m)
+	}
+	if len(result.Errors) > 0 {
+		return result, ErrInvalidImport
+	}
+	if len(items) == 0 {
+		return result, nil
+	}
+
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		result.Inserted, result.Updated, err = UpsertStocks(ctx, tx, items)
+		return err
+	})
+	if err != nil {
+		return ImportResult{Rows: len(rows)}, err
+	}
+	result.Skipped = len(items) - result.Inserted - result.Updated
+	return result, nil
+}
+
+// resolveColumns devuelve el índice de columna de cada campo según los encabezados
+func resolveColumns(header []string, mapping ColumnMapping) (map[string]int, error) {
+	byName := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.TrimPrefix(h, "\ufeff")
+		byName[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	known := make(map[string]bool, len(importFields))
+	for _, f := range importFields {
+		known[f] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("campo desconocido en el mapeo: %q", field)
+		}
+	}
+
+	columns := make(map[string]int, len(importFields))
+	for _, field := range importFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		idx, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			if mapped {
+				return nil, fmt.Errorf("no existe la columna %q mapeada a %s", name, field)
+			}
+			continue
+		}
+		columns[field] = idx
+	}
+	return columns, nil
+}
+
+func readXLSX(r io.Reader, sheet string) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	return f.GetRows(sheet)
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+const importCSV = `Symbol,Broker,Action,Rating,Previous Rating,From,To,Date,company
+aapl,Goldman,target raised by,Buy,Buy,"$150.00","$1,180.00",2025-05-02,Apple Inc.
+GOOG,Morgan,initiated by,Neutral,,$100,$120,2025-05-01T10:00:00Z,Alphabet Inc.
+`
+
+var importMapping = ColumnMapping{
+	"ticker":      "Symbol",
+	"brokerage":   "Broker",
+	"action":      "Action",
+	"rating_to":   "Rating",
+	"rating_from": "Previous Rating",
+	"target_from": "From",
+	"target_to":   "To",
+	"time":        "Date",
+}
+
+func TestImportStocks_CSV(t *testing.T) {
+	db := setupTestDB(t)
+
+	rows, err := ParseImportFile(strings.NewReader(importCSV), ImportCSV, importMapping, "")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "Apple Inc.", rows[0].Item.Company)
+
+	result, err := ImportStocks(context.Background(), db, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+
+	// El ticker se normaliza al convertir, igual que en el loader
+	var stored models.StockItem
+	err = db.NewSelect().Model(&stored).Where("ticker = ?", "AAPL").Scan(context.Background())
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.TargetToValue) {
+		assert.Equal(t, models.Amount(1180), *stored.TargetToValue)
+	}
+
+	// Reimportar no duplica
+	result, err = ImportStocks(context.Background(), db, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Inserted)
+	assert.Equal(t, 2, result.Skipped)
+
+	// El mismo rating desde la API tiene la misma clave natural
+	fromAPI := dto.StockItem{
+		Ticker:     "aapl",
+		Brokerage:  "Goldman",
+		Action:     "target raised by",
+		RatingTo:   "Buy",
+		RatingFrom: "Buy",
+		TargetFrom: "$150.00",
+		TargetTo:   "$1,180.00",
+		Company:    "Apple Inc.",
+		Time:       "2025-05-02T00:00:00Z",
+	}
+	synced, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: NewFixtureSource(10, fromAPI)})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, synced.Inserted)
+	assert.Equal(t, 1, synced.Skipped)
+}
+
+func TestImportStocks_InvalidRowsAreAllOrNothing(t *testing.T) {
+	db := setupTestDB(t)
+	csv := importCSV + "MSFT,Goldman,target raised by,Buy,Buy,$10,N/A,2025-05-01,Microsoft\n"
+
+	rows, err := ParseImportFile(strings.NewReader(csv), ImportCSV, importMapping, "")
+	assert.NoError(t, err)
+
+	result, err := ImportStocks(context.Background(), db, rows)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, 4, result.Errors[0].Row)
+		assert.Contains(t, result.Errors[0].Error, "target_to")
+	}
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestParseImportFile_XLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	assert.NoError(t, f.SetSheetRow(sheet, "A1", &[]string{"ticker", "brokerage", "action", "rating_to", "target_from", "target_to", "time"}))
+	assert.NoError(t, f.SetSheetRow(sheet, "A2", &[]string{"AAPL", "Goldman", "upgraded by", "Buy", "$150", "$180", "2025-05-02"}))
+
+	var buf bytes.Buffer
+	assert.NoError(t, f.Write(&buf))
+
+	rows, err := ParseImportFile(&buf, ImportXLSX, nil, "")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "Goldman", rows[0].Item.Brokerage)
+		_, err := rows[0].Item.ToModel()
+		assert.NoError(t, err)
+	}
+}
+
+func TestParseImportFile_UnknownMappedColumn(t *testing.T) {
+	_, err := ParseImportFile(strings.NewReader(importCSV), ImportCSV, ColumnMapping{"ticker": "Ticker Symbol"}, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/uptrace/bun"
+)
+
+// Orígenes de una sincronización
+const (
+	TriggerStartup  = "startup"
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+	TriggerReplay   = "replay"
+)
+
+// startRun registra una ejecución nueva, o marca como en curso la que se retoma
+func startRun(ctx context.Context, db bun.IDB, cp *models.IngestionCheckpoint, trigger string) (*models.IngestionRun, error) {
+	run := &models.IngestionRun{ID: cp.RunID}
+	err := db.NewSelect().Model(run).WherePK().Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error buscando ejecución: %v", err)
+	}
+	if err == nil {
+		run.Status = models.IngestionRunning
+		run.Error = ""
+		run.FinishedAt = time.Time{}
+		_, err = db.NewUpdate().Model(run).Column("status", "error", "finished_at").WherePK().Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error retomando ejecución: %v", err)
+		}
+		return run, nil
+	}
+
+	run = &models.IngestionRun{
+		ID:        cp.RunID,
+		Trigger:   trigger,
+		Mode:      cp.Mode,
+		Status:    models.IngestionRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	if _, err := db.NewInsert().Model(run).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("error registrando ejecución: %v", err)
+	}
+	return run, nil
+}
+
+// finishRun acumula los contadores de la sesión y guarda el resultado final
+func finishRun(ctx context.Context, db bun.IDB, run *models.IngestionRun, cp *models.IngestionCheckpoint, result SyncResult, syncErr error) error {
+	run.FinishedAt = time.Now().UTC()
+	run.Pages = cp.Page
+	run.Inserted += result.Inserted
+	run.Updated += result.Updated
+	run.Skipped += result.Skipped
+	run.Rejected += result.Rejected
+	run.Status = models.IngestionSucceeded
+	run.Error = ""
+	if syncErr != nil {
+		run.Status = models.IngestionFailed
+		run.Error = syncErr.Error()
+	}
+
+	_, err := db.NewUpdate().Model(run).WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error guardando ejecución: %v", err)
+	}
+	return nil
+}
+
+// ListIngestionRuns devuelve las ejecuciones más recientes primero
+func ListIngestionRuns(ctx context.Context, db bun.IDB, limit int) ([]models.IngestionRun, error) {
+	runs := []models.IngestionRun{}
+	err := db.NewSelect().
+		Model(&runs).
+		Order("started_at DESC").
+		Limit(limit).
+		Scan(ctx)
+	return runs, err
+}
+
+// GetIngestionRun devuelve una ejecución con su checkpoint
+func GetIngestionRun(ctx context.Context, db bun.IDB, id string) (*models.IngestionRun, *models.IngestionCheckpoint, error) {
+	run := &models.IngestionRun{ID: id}
+	if err := db.NewSelect().Model(run).WherePK().Scan(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	cp := &models.IngestionCheckpoint{RunID: id}
+	err := db.NewSelect().Model(cp).WherePK().Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return run, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return run, cp, nil
+}
+
+// ListRejectedItems devuelve los items rechazados de una ejecución y el total
+func ListRejectedItems(ctx context.Context, db bun.IDB, runID string, limit, offset int) ([]models.StockItemRejected, int, error) {
+	rejected := []models.StockItemRejected{}
+	total, err := db.NewSelect().
+		Model(&rejected).
+		Where("run_id = ?", runID).
+		Order("page ASC", "id ASC").
+		Limit(limit).
+		Offset(offset).
+		ScanAndCount(ctx)
+	return rejected, total, err
+}
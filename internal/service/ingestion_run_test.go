@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncStocks_RecordsIngestionRuns(t *testing.T) {
+	db := setupTestDB(t)
+	pages := map[string]APIResponse{
+		"": page("p2", item("AAPL", "2025-05-02T00:00:00Z")),
+	}
+	source := fakeAPI(t, pages)
+
+	failed, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Trigger: TriggerManual, Source: source})
+	assert.Error(t, err)
+
+	run, _, err := GetIngestionRun(context.Background(), db, failed.RunID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.IngestionFailed, run.Status)
+	assert.Equal(t, TriggerManual, run.Trigger)
+	assert.Equal(t, 1, run.Pages)
+	assert.Equal(t, 1, run.Inserted)
+	assert.NotEmpty(t, run.Error)
+
+	pages["p2"] = page("", item("GOOG", "2025-05-01T00:00:00Z"))
+	resumed, err := SyncStocks(context.Background(), db, SyncOptions{Resume: true, Trigger: TriggerSchedule, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, failed.RunID, resumed.RunID)
+
+	run, cp, err := GetIngestionRun(context.Background(), db, failed.RunID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.IngestionSucceeded, run.Status)
+	assert.Equal(t, 2, run.Pages)
+	assert.Equal(t, 2, run.Inserted)
+	assert.Empty(t, run.Error)
+	assert.True(t, cp.Completed)
+
+	runs, err := ListIngestionRuns(context.Background(), db, 10)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
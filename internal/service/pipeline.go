@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/uptrace/bun"
+)
+
+// DefaultPrefetchPages es cuántas páginas descarga el pipeline por adelantado
+const DefaultPrefetchPages = 8
+
+// errPipelineIncomplete indica que el escritor terminó sin llegar a la última página
+var errPipelineIncomplete = errors.New("la sincronización terminó antes de la última página")
+
+// fetchedPage es una página descargada y validada, pendiente de escribir
+type fetchedPage struct {
+	num          int
+	nextPage     string
+	items        []models.StockItem
+	rejected     []models.StockItemRejected
+	reachedKnown bool
+	last         bool
+}
+
+// syncPipeline descarga páginas en una goroutine mientras otra las escribe.
+// Como mucho hay prefetch páginas descargadas esperando: el productor se
+// bloquea hasta que el escritor confirme alguna.
+//
+// Hay un solo escritor a propósito, no un pool: cada página se confirma en la
+// misma transacción que su checkpoint y en orden, así que el checkpoint nunca
+// apunta por delante ni por detrás de lo escrito. Con varios escritores una
+// página posterior podría confirmarse antes que una anterior y habría que
+// elegir entre perder esa garantía o serializar los commits igualmente. Lo
+// que se paraleliza es la descarga, que es lo lento; con SQLite, además, solo
+// hay una conexión.
+type syncPipeline struct {
+	db       *bun.DB
+	source   StockSource
+	archive  *PageArchive
+	prefetch int
+
+	// beforeCommit, si no es nil, se llama dentro de la transacción de cada
+	// página justo antes de confirmarla; los tests lo usan para simular una caída
+	beforeCommit func(page int) error
+}
+
+func newSyncPipeline(db *bun.DB, source StockSource, archive *PageArchive, prefetch int) *syncPipeline {
+	if prefetch <= 0 {
+		prefetch = DefaultPrefetchPages
+	}
+	return &syncPipeline{db: db, source: source, archive: archive, prefetch: prefetch}
+}
+
+// run procesa las páginas a partir del checkpoint hasta terminar o fallar.
+// Si falla una descarga, las páginas ya descargadas se terminan de escribir
+// antes de devolver el error; si falla una escritura se cancela la descarga.
+// Cada página se escribe en orden y en la misma transacción que su
+// checkpoint, así que una caída nunca deja filas escritas sin que el
+// checkpoint las cuente.
+func (p *syncPipeline) run(ctx context.Context, cp *models.IngestionCheckpoint, result *SyncResult) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	pages := make(chan fetchedPage, p.prefetch)
+
+	// writePages modifica cp; el productor trabaja sobre una copia
+	start := *cp
+
+	var fetchErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(pages)
+		fetchErr = p.fetchPages(ctx, start, pages)
+	}()
+
+	err := p.writePages(ctx, cp, result, pages)
+	if err != nil {
+		cancel(err)
+	}
+	<-done
+
+	if errors.Is(err, errPipelineIncomplete) && fetchErr != nil {
+		return fetchErr
+	}
+	return err
+}
+
+// fetchPages sigue los tokens de la fuente desde el checkpoint y envía cada
+// página validada al canal. Se detiene en la última página o al primer error.
+func (p *syncPipeline) fetchPages(ctx context.Context, cp models.IngestionCheckpoint, out chan<- fetchedPage) error {
+	token := cp.NextPage
+	for num := cp.Page + 1; ; num++ {
+		fmt.Printf("📦 Descargando página %d...\n", num)
+		page, err := p.source.FetchPage(ctx, token)
+		if err != nil {
+			return err
+		}
+
+		if p.archive != nil && page.Raw != nil {
+			if err := p.archive.Save(cp.RunID, num, page.Raw); err != nil {
+				return err
+			}
+		}
+
+		valid, rejected := decodeItems(page.Items, cp.RunID, num)
+		items, reachedKnown := newerThan(valid, cp.Since)
+		last := reachedKnown || page.NextPage == ""
+
+		select {
+		case out <- fetchedPage{
+			num:          num,
+			nextPage:     page.NextPage,
+			items:        items,
+			rejected:     rejected,
+			reachedKnown: reachedKnown,
+			last:         last,
+		}:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+
+		if last {
+			return nil
+		}
+		token = page.NextPage
+	}
+}
+
+// writePages escribe las páginas a medida que llegan, cada una en su
+// propia transacción, y suma sus totales al resultado
+func (p *syncPipeline) writePages(ctx context.Context, cp *models.IngestionCheckpoint, result *SyncResult, pages <-chan fetchedPage) error {
+	for page := range pages {
+		inserted, updated, err := p.writePage(ctx, cp, page)
+		if err != nil {
+			return err
+		}
+
+		result.Pages++
+		result.Inserted += inserted
+		result.Updated += updated
+		result.Skipped += len(page.items) - inserted - updated
+		result.Rejected += len(page.rejected)
+		if len(page.rejected) > 0 {
+			fmt.Printf("🚫 %d items rechazados en la página %d.\n", len(page.rejected), page.num)
+		}
+
+		if page.reachedKnown {
+			fmt.Println("⏹️  Se alcanzaron datos ya almacenados, fin de la sincronización incremental.")
+		}
+		if page.last {
+			return nil
+		}
+	}
+
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+	return errPipelineIncomplete
+}
+
+// writePage inserta los items y los rechazados de una página y avanza el
+// checkpoint en una sola transacción: o queda todo escrito o nada. Los
+// rechazados previos de la misma página se reemplazan por si se repite al retomar.
+func (p *syncPipeline) writePage(ctx context.Context, cp *models.IngestionCheckpoint, page fetchedPage) (inserted, updated int, err error) {
+	next := *cp
+	err = p.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(page.items) > 0 {
+			var err error
+			if inserted, updated, err = UpsertStocks(ctx, tx, page.items); err != nil {
+				return err
+			}
+		}
+		if len(page.rejected) > 0 {
+			_, err := tx.NewDelete().
+				Model((*models.StockItemRejected)(nil)).
+				Where("run_id = ? AND page = ?", cp.RunID, page.num).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("error limpiando items rechazados: %v", err)
+			}
+			if _, err := tx.NewInsert().Model(&page.rejected).Exec(ctx); err != nil {
+				return fmt.Errorf("error guardando items rechazados: %v", err)
+			}
+		}
+
+		next.Page = page.num
+		next.NextPage = page.nextPage
+		next.RowsWritten += inserted
+		next.Completed = page.last
+		if err := saveCheckpoint(ctx, tx, &next); err != nil {
+			return err
+		}
+		if p.beforeCommit != nil {
+			return p.beforeCommit(page.num)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	*cp = next
+	return inserted, updated, nil
+}
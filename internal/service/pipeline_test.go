@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingSource devuelve error a partir de la página indicada
+type failingSource struct {
+	StockSource
+	failAt string
+}
+
+func (s failingSource) FetchPage(ctx context.Context, token string) (Page, error) {
+	if token == s.failAt {
+		return Page{}, errors.New("fuente caída")
+	}
+	return s.StockSource.FetchPage(ctx, token)
+}
+
+func manyItems(n int) []dto.StockItem {
+	start := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	items := make([]dto.StockItem, n)
+	for i := range items {
+		items[i] = item(fmt.Sprintf("T%03d", i), start.Add(-time.Duration(i)*time.Hour).Format(time.RFC3339))
+	}
+	return items
+}
+
+func TestSyncStocks_PipelineWritesAllPages(t *testing.T) {
+	db := setupTestDB(t)
+	source := NewFixtureSource(3, manyItems(50)...)
+
+	opts := SyncOptions{Mode: SyncFull, Source: source, Prefetch: 2}
+	result, err := SyncStocks(context.Background(), db, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 17, result.Pages)
+	assert.Equal(t, 50, result.Inserted)
+
+	var cp models.IngestionCheckpoint
+	assert.NoError(t, db.NewSelect().Model(&cp).Where("run_id = ?", result.RunID).Scan(context.Background()))
+	assert.True(t, cp.Completed)
+	assert.Equal(t, 17, cp.Page)
+	assert.Equal(t, 50, cp.RowsWritten)
+}
+
+func TestSyncStocks_PipelineFetchErrorKeepsWrittenPages(t *testing.T) {
+	db := setupTestDB(t)
+	// La cuarta página (offset 9) falla; las tres anteriores deben quedar escritas
+	source := failingSource{StockSource: NewFixtureSource(3, manyItems(12)...), failAt: "9"}
+
+	_, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.ErrorContains(t, err, "fuente caída")
+
+	cp, err := LatestUnfinishedCheckpoint(context.Background(), db)
+	assert.NoError(t, err)
+	if assert.NotNil(t, cp) {
+		assert.Equal(t, 3, cp.Page)
+		assert.Equal(t, "9", cp.NextPage)
+		assert.Equal(t, 9, cp.RowsWritten)
+	}
+}
+
+func TestSyncStocks_CrashBeforeCommitKeepsPageAndCheckpointTogether(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	source := NewFixtureSource(3, manyItems(9)...)
+
+	// La ejecución muere con la página 2 insertada pero sin confirmar
+	cp, err := newCheckpoint(ctx, db, "", SyncFull, time.Time{})
+	assert.NoError(t, err)
+	pipeline := newSyncPipeline(db, source, nil, 0)
+	pipeline.beforeCommit = func(page int) error {
+		if page == 2 {
+			return errors.New("proceso terminado")
+		}
+		return nil
+	}
+	var first SyncResult
+	assert.ErrorContains(t, pipeline.run(ctx, cp, &first), "proceso terminado")
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	saved, err := LatestUnfinishedCheckpoint(ctx, db)
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, 1, saved.Page)
+		assert.Equal(t, 3, saved.RowsWritten)
+	}
+
+	// Al retomar, las páginas 2 y 3 se insertan de nuevo sin contarse como existentes
+	result, err := SyncStocks(ctx, db, SyncOptions{Mode: SyncFull, Resume: true, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, cp.RunID, result.RunID)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 6, result.Inserted)
+	assert.Equal(t, 0, result.Skipped)
+
+	var final models.IngestionCheckpoint
+	assert.NoError(t, db.NewSelect().Model(&final).Where("run_id = ?", cp.RunID).Scan(ctx))
+	assert.True(t, final.Completed)
+	assert.Equal(t, 9, final.RowsWritten)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/uptrace/bun"
+)
+
+// Estados de arranque del servicio
+const (
+	ReadinessWarmingUp = "warming_up"
+	ReadinessReady     = "ready"
+	ReadinessFailed    = "failed"
+)
+
+// ReadinessStatus describe si el servicio ya tiene datos para responder
+type ReadinessStatus struct {
+	Ready  bool      `json:"ready"`
+	Status string    `json:"status"`
+	Since  time.Time `json:"since"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Readiness registra si terminó la carga inicial. El servidor arranca sin
+// esperarla y los endpoints de datos responden 503 mientras no esté lista.
+type Readiness struct {
+	mu     sync.RWMutex
+	status ReadinessStatus
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{status: ReadinessStatus{Status: ReadinessWarmingUp, Since: time.Now().UTC()}}
+}
+
+// MarkReady indica que ya hay datos para servir; una vez lista no vuelve atrás
+func (r *Readiness) MarkReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.status.Ready {
+		r.status = ReadinessStatus{Ready: true, Status: ReadinessReady, Since: time.Now().UTC()}
+	}
+}
+
+// Fail registra que la carga inicial falló. No tiene efecto si ya estaba lista.
+func (r *Readiness) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.status.Ready {
+		r.status = ReadinessStatus{Status: ReadinessFailed, Since: time.Now().UTC(), Error: err.Error()}
+	}
+}
+
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status.Ready
+}
+
+// Status devuelve una copia del estado actual
+func (r *Readiness) Status() ReadinessStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status
+}
+
+// HasStocks indica si stock_items ya tiene datos
+func HasStocks(ctx context.Context, db *bun.DB) (bool, error) {
+	exists, err := db.NewSelect().Model((*models.StockItem)(nil)).Limit(1).Exists(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error consultando stock_items: %v", err)
+	}
+	return exists, nil
+}
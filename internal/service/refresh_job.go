@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// DefaultRefreshInterval es el intervalo por defecto del refresco periódico
+const DefaultRefreshInterval = 15 * time.Minute
+
+// ErrRefreshInProgress se devuelve al pedir un refresco mientras otro está en curso
+var ErrRefreshInProgress = errors.New("ya hay un refresco en curso")
+
+// ErrRefreshStopped se devuelve al pedir un refresco cuando el job ya se está deteniendo
+var ErrRefreshStopped = errors.New("el refresco periódico se está deteniendo")
+
+// ErrNoRefreshSource se devuelve al pedir un refresco a un job creado sin fuente
+var ErrNoRefreshSource = errors.New("no hay una fuente configurada para sincronizar")
+
+// RefreshStatus describe la última ejecución del job de refresco
+type RefreshStatus struct {
+	Running      bool      `json:"running"`
+	Interval     string    `json:"interval"`
+	LastRunID    string    `json:"last_run_id,omitempty"`
+	LastRun      time.Time `json:"last_run"`
+	DurationMs   int64     `json:"duration_ms"`
+	RowsInserted int       `json:"rows_inserted"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// RefreshJob sincroniza los stocks de forma periódica en segundo plano.
+// Nunca ejecuta dos refrescos a la vez.
+type RefreshJob struct {
+	db       *bun.DB
+	interval time.Duration
+	source   StockSource
+	archive  *PageArchive
+
+	lease sync.Mutex     // se toma durante cada refresco
+	wg    sync.WaitGroup // refrescos lanzados con Trigger
+
+	mu        sync.RWMutex
+	ctx       context.Context // contexto de Start, usado por los refrescos manuales
+	stopped   bool            // Start terminó: ya no se aceptan refrescos manuales
+	status    RefreshStatus
+	readiness *Readiness // se marca lista con el primer refresco exitoso
+}
+
+// NewRefreshJob crea el job; cada refresco lee de source y archiva en archive
+// (nil para no archivar). Un intervalo de 0 desactiva el refresco periódico.
+func NewRefreshJob(db *bun.DB, interval time.Duration, source StockSource, archive *PageArchive) *RefreshJob {
+	return &RefreshJob{
+		db:       db,
+		interval: interval,
+		source:   source,
+		archive:  archive,
+		ctx:      context.Background(),
+		status:   RefreshStatus{Interval: interval.String()},
+	}
+}
+
+// Start ejecuta un refresco incremental en cada intervalo hasta que ctx se cancele.
+// Retorna cuando ctx termina y los refrescos en curso, si los hay, se detuvieron.
+func (j *RefreshJob) Start(ctx context.Context) {
+	j.mu.Lock()
+	j.ctx = ctx
+	j.mu.Unlock()
+	defer j.stop()
+
+	if j.interval <= 0 {
+		log.Println("⏸️  Refresco periódico desactivado.")
+		<-ctx.Done()
+		return
+	}
+
+	log.Printf("⏰ Refresco periódico cada %s.", j.interval)
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Refresco periódico detenido.")
+			return
+		case <-ticker.C:
+			if _, err := j.RunOnce(ctx); err != nil && !errors.Is(err, ErrRefreshInProgress) {
+				log.Printf("⚠️  Error en el refresco periódico: %v", err)
+			}
+		}
+	}
+}
+
+// Bootstrap ejecuta la carga inicial con las opciones dadas y actualiza readiness:
+// queda lista si la carga termina bien, o en estado fallido si no. Los refrescos
+// posteriores que terminen bien también la marcan lista.
+func (j *RefreshJob) Bootstrap(ctx context.Context, opts SyncOptions, readiness *Readiness) (SyncResult, error) {
+	j.mu.Lock()
+	j.readiness = readiness
+	j.mu.Unlock()
+
+	j.lease.Lock()
+	defer j.lease.Unlock()
+
+	result, err := j.run(ctx, opts)
+	if err != nil {
+		readiness.Fail(err)
+	}
+	return result, err
+}
+
+// RunOnce ejecuta una sincronización incremental inmediatamente, retomando
+// antes la última ejecución interrumpida si la hay
+func (j *RefreshJob) RunOnce(ctx context.Context) (SyncResult, error) {
+	if !j.lease.TryLock() {
+		return SyncResult{}, ErrRefreshInProgress
+	}
+	defer j.lease.Unlock()
+
+	return j.run(ctx, SyncOptions{Mode: SyncIncremental, Resume: true, Trigger: TriggerSchedule})
+}
+
+// Trigger lanza en segundo plano una sincronización manual y devuelve su run ID
+func (j *RefreshJob) Trigger(mode SyncMode) (string, error) {
+	if j.source == nil {
+		return "", ErrNoRefreshSource
+	}
+	if !j.lease.TryLock() {
+		return "", ErrRefreshInProgress
+	}
+
+	// wg.Add se hace con mu tomado para que no coincida con el Wait de stop
+	j.mu.Lock()
+	if j.stopped || j.ctx.Err() != nil {
+		j.mu.Unlock()
+		j.lease.Unlock()
+		return "", ErrRefreshStopped
+	}
+	ctx := j.ctx
+	j.wg.Add(1)
+	j.mu.Unlock()
+
+	runID := uuid.NewString()
+	go func() {
+		defer j.wg.Done()
+		defer j.lease.Unlock()
+
+		if _, err := j.run(ctx, SyncOptions{Mode: mode, Trigger: TriggerManual, RunID: runID}); err != nil {
+			log.Printf("⚠️  Error en el refresco manual %s: %v", runID, err)
+		}
+	}()
+	return runID, nil
+}
+
+// stop deja de aceptar refrescos manuales y espera a que terminen los lanzados
+func (j *RefreshJob) stop() {
+	j.mu.Lock()
+	j.stopped = true
+	j.mu.Unlock()
+
+	j.wg.Wait()
+}
+
+// run sincroniza y actualiza el estado; el llamador debe tener el lease
+func (j *RefreshJob) run(ctx context.Context, opts SyncOptions) (SyncResult, error) {
+	opts.Source = j.source
+	opts.Archive = j.archive
+
+	j.mu.Lock()
+	j.status.Running = true
+	j.mu.Unlock()
+
+	start := time.Now()
+	result, err := SyncStocks(ctx, j.db, opts)
+
+	j.mu.Lock()
+	j.status.Running = false
+	j.status.LastRunID = result.RunID
+	j.status.LastRun = start
+	j.status.DurationMs = time.Since(start).Milliseconds()
+	j.status.RowsInserted = result.Inserted
+	j.status.LastError = ""
+	if err != nil {
+		j.status.LastError = err.Error()
+	}
+	readiness := j.readiness
+	j.mu.Unlock()
+
+	if err == nil && readiness != nil {
+		readiness.MarkReady()
+	}
+
+	return result, err
+}
+
+// Status devuelve una copia del estado actual del job
+func (j *RefreshJob) Status() RefreshStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.status
+}
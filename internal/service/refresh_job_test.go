@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshJob_RunOnceUpdatesStatus(t *testing.T) {
+	db := setupTestDB(t)
+	source := fakeAPI(t, map[string]APIResponse{
+		"": page("", item("AAPL", "2025-05-02T00:00:00Z")),
+	})
+
+	job := NewRefreshJob(db, DefaultRefreshInterval, source, nil)
+	_, err := job.RunOnce(context.Background())
+	assert.NoError(t, err)
+
+	status := job.Status()
+	assert.False(t, status.Running)
+	assert.False(t, status.LastRun.IsZero())
+	assert.Equal(t, 1, status.RowsInserted)
+	assert.Empty(t, status.LastError)
+}
+
+func TestRefreshJob_RunOnceDoesNotOverlap(t *testing.T) {
+	db := setupTestDB(t)
+	job := NewRefreshJob(db, DefaultRefreshInterval, NewFixtureSource(1), nil)
+
+	job.lease.Lock()
+	defer job.lease.Unlock()
+
+	_, err := job.RunOnce(context.Background())
+	assert.ErrorIs(t, err, ErrRefreshInProgress)
+}
+
+func TestRefreshJob_TriggerWithoutSource(t *testing.T) {
+	db := setupTestDB(t)
+	job := NewRefreshJob(db, 0, nil, nil)
+
+	_, err := job.Trigger(SyncFull)
+	assert.ErrorIs(t, err, ErrNoRefreshSource)
+
+	runs, err := ListIngestionRuns(context.Background(), db, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestRefreshJob_TriggerAfterStop(t *testing.T) {
+	db := setupTestDB(t)
+	job := NewRefreshJob(db, 0, NewFixtureSource(1, item("AAPL", "2025-05-02T00:00:00Z")), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	job.Start(ctx)
+
+	_, err := job.Trigger(SyncFull)
+	assert.ErrorIs(t, err, ErrRefreshStopped)
+
+	// El lease queda libre para que un refresco posterior no se bloquee
+	assert.True(t, job.lease.TryLock())
+	job.lease.Unlock()
+}
+
+func TestRefreshJob_BootstrapUpdatesReadiness(t *testing.T) {
+	db := setupTestDB(t)
+	pages := map[string]APIResponse{}
+	source := fakeAPI(t, pages)
+
+	job := NewRefreshJob(db, DefaultRefreshInterval, source, nil)
+	readiness := NewReadiness()
+
+	// Sin datos en la API la carga inicial falla y el servicio no queda listo
+	_, err := job.Bootstrap(context.Background(), SyncOptions{Mode: SyncFull, Trigger: TriggerStartup}, readiness)
+	assert.Error(t, err)
+	assert.False(t, readiness.Ready())
+	assert.Equal(t, ReadinessFailed, readiness.Status().Status)
+
+	// El siguiente refresco exitoso lo marca listo
+	pages[""] = page("", item("AAPL", "2025-05-02T00:00:00Z"))
+	_, err = job.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, readiness.Ready())
+
+	hasData, err := HasStocks(context.Background(), db)
+	assert.NoError(t, err)
+	assert.True(t, hasData)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/scoring"
+	"github.com/uptrace/bun"
+)
+
+var (
+	// ErrProfileConflict indica que otro cambio al mismo perfil se guardó antes
+	ErrProfileConflict = errors.New("el perfil cambió mientras se guardaba, vuelve a intentarlo")
+	// ErrInvalidProfileName indica un nombre de perfil con caracteres no permitidos
+	ErrInvalidProfileName = errors.New("nombre de perfil inválido: usa minúsculas, números, '-' o '_' (máximo 64)")
+)
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ListScoringProfiles devuelve todos los perfiles de pesos ordenados por nombre
+func ListScoringProfiles(ctx context.Context, db bun.IDB) ([]models.ScoringProfile, error) {
+	var rows []models.ScoringProfile
+	if err := db.NewSelect().Model(&rows).Order("name").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("error listando perfiles de scoring: %v", err)
+	}
+	return rows, nil
+}
+
+// GetScoringProfile devuelve la versión actual de un perfil, o sql.ErrNoRows si no existe
+func GetScoringProfile(ctx context.Context, db bun.IDB, name string) (models.ScoringProfile, error) {
+	var row models.ScoringProfile
+	err := db.NewSelect().Model(&row).Where("name = ?", name).Scan(ctx)
+	return row, err
+}
+
+// ProfileScorer devuelve un Scorer con los pesos actuales del perfil; se lee
+// en cada llamada, así que los cambios aplican sin reiniciar
+func ProfileScorer(ctx context.Context, db bun.IDB, name string) (scoring.Scorer, error) {
+	profile, err := GetScoringProfile(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	return scoring.DecodeWeights(profile.Weights)
+}
+
+// SaveScoringProfile crea el perfil o guarda una nueva versión de sus pesos,
+// registrando quién hizo el cambio. created indica si el perfil no existía.
+func SaveScoringProfile(ctx context.Context, db *bun.DB, name string, weights scoring.Weights, changedBy string) (profile models.ScoringProfile, created bool, err error) {
+	if !profileNamePattern.MatchString(name) {
+		return profile, false, ErrInvalidProfileName
+	}
+	if err := weights.Validate(); err != nil {
+		return profile, false, err
+	}
+	raw, err := json.Marshal(weights)
+	if err != nil {
+		return profile, false, fmt.Errorf("error serializando el perfil: %v", err)
+	}
+
+	row := models.ScoringProfile{
+		Name:      name,
+		Weights:   raw,
+		UpdatedBy: changedBy,
+		UpdatedAt: time.Now().UTC(),
+	}
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := currentProfileVersion(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+		if row.Version, err = nextProfileVersion(ctx, tx, name); err != nil {
+			return err
+		}
+
+		var res sql.Result
+		if current == 0 {
+			created = true
+			res, err = tx.NewInsert().Model(&row).On("CONFLICT DO NOTHING").Returning("NULL").Exec(ctx)
+		} else {
+			res, err = tx.NewUpdate().Model(&row).WherePK().Where("version = ?", current).Exec(ctx)
+		}
+		if err := checkProfileWrite(res, err); err != nil {
+			return err
+		}
+
+		return addProfileVersion(ctx, tx, models.ScoringProfileVersion{
+			Name:      name,
+			Version:   row.Version,
+			Weights:   row.Weights,
+			ChangedBy: changedBy,
+			ChangedAt: row.UpdatedAt,
+		})
+	})
+	if err != nil {
+		return profile, false, err
+	}
+	return row, created, nil
+}
+
+// DeleteScoringProfile borra el perfil dejando constancia en el historial.
+// Devuelve sql.ErrNoRows si no existe.
+func DeleteScoringProfile(ctx context.Context, db *bun.DB, name, changedBy string) error {
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		current, err := GetScoringProfile(ctx, tx, name)
+		if err != nil {
+			return err // sql.ErrNoRows si no existe
+		}
+		next, err := nextProfileVersion(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().
+			Model((*models.ScoringProfile)(nil)).
+			Where("name = ? AND version = ?", name, current.Version).
+			Exec(ctx)
+		if err := checkProfileWrite(res, err); err != nil {
+			return err
+		}
+
+		return addProfileVersion(ctx, tx, models.ScoringProfileVersion{
+			Name:      name,
+			Version:   next,
+			Weights:   current.Weights,
+			Deleted:   true,
+			ChangedBy: changedBy,
+			ChangedAt: time.Now().UTC(),
+		})
+	})
+}
+
+// ScoringProfileHistory devuelve las versiones de un perfil, de la más nueva a
+// la más vieja, incluidas las de un perfil ya borrado. sql.ErrNoRows si nunca existió.
+func ScoringProfileHistory(ctx context.Context, db bun.IDB, name string) ([]models.ScoringProfileVersion, error) {
+	var rows []models.ScoringProfileVersion
+	err := db.NewSelect().Model(&rows).Where("name = ?", name).Order("version DESC").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando el historial del perfil: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return rows, nil
+}
+
+// currentProfileVersion devuelve la versión vigente del perfil, o 0 si no existe
+func currentProfileVersion(ctx context.Context, db bun.IDB, name string) (int, error) {
+	var row models.ScoringProfile
+	err := db.NewSelect().Model(&row).Column("version").Where("name = ?", name).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error consultando el perfil: %v", err)
+	}
+	return row.Version, nil
+}
+
+// nextProfileVersion sigue la numeración del historial, así un perfil borrado
+// y vuelto a crear no repite versiones
+func nextProfileVersion(ctx context.Context, db bun.IDB, name string) (int, error) {
+	var last sql.NullInt64
+	err := db.NewSelect().
+		Model((*models.ScoringProfileVersion)(nil)).
+		ColumnExpr("MAX(version)").
+		Where("name = ?", name).
+		Scan(ctx, &last)
+	if err != nil {
+		return 0, fmt.Errorf("error consultando el historial del perfil: %v", err)
+	}
+	return int(last.Int64) + 1, nil
+}
+
+// checkProfileWrite convierte una escritura que no afectó filas en ErrProfileConflict
+func checkProfileWrite(res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("error guardando el perfil: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrProfileConflict
+	}
+	return nil
+}
+
+func addProfileVersion(ctx context.Context, db bun.IDB, version models.ScoringProfileVersion) error {
+	// Si otro cambio ya usó este número de versión, el perfil cambió en el medio
+	res, err := db.NewInsert().Model(&version).On("CONFLICT DO NOTHING").Returning("NULL").Exec(ctx)
+	return checkProfileWrite(res, err)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/Carlosmercg/stock-analyzer/internal/scoring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoringProfiles_Versioning(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	profile, created, err := SaveScoringProfile(ctx, db, "analistas", scoring.DefaultWeights, "ana")
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, 1, profile.Version)
+
+	generous := scoring.Weights{Growth: 1, Ratings: map[string]float64{"buy": 50}}
+	profile, created, err = SaveScoringProfile(ctx, db, "analistas", generous, "luis")
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, 2, profile.Version)
+	assert.Equal(t, "luis", profile.UpdatedBy)
+
+	// El scorer usa los pesos vigentes
+	scorer, err := ProfileScorer(ctx, db, "analistas")
+	assert.NoError(t, err)
+	item := models.StockItem{TargetFrom: "$10", TargetTo: "$11", RatingTo: "Buy"}
+	item.NormalizeTargets()
+	score, _ := scorer.Score(item)
+	assert.InDelta(t, 60, score, 0.001)
+
+	// Al borrar y volver a crear, la numeración sigue
+	assert.NoError(t, DeleteScoringProfile(ctx, db, "analistas", "ana"))
+	_, err = GetScoringProfile(ctx, db, "analistas")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, DeleteScoringProfile(ctx, db, "analistas", "ana"), sql.ErrNoRows)
+
+	profile, created, err = SaveScoringProfile(ctx, db, "analistas", generous, "luis")
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, 4, profile.Version)
+
+	history, err := ScoringProfileHistory(ctx, db, "analistas")
+	assert.NoError(t, err)
+	if assert.Len(t, history, 4) {
+		assert.Equal(t, 4, history[0].Version)
+		assert.True(t, history[1].Deleted)
+		assert.JSONEq(t, `{"growth":1,"ratings":{"buy":50}}`, string(history[1].Weights))
+		assert.Equal(t, "ana", history[3].ChangedBy)
+		assert.JSONEq(t, `{"growth":1,"ratings":{"buy":50}}`, string(history[0].Weights))
+	}
+
+	_, err = ScoringProfileHistory(ctx, db, "nunca")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, _, err = SaveScoringProfile(ctx, db, "Con Espacios", generous, "ana")
+	assert.ErrorIs(t, err, ErrInvalidProfileName)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+)
+
+// DefaultPageSize es el tamaño de página de las fuentes locales
+const DefaultPageSize = 100
+
+// Page es una página de items crudos; cada item se decodifica como dto.StockItem
+type Page struct {
+	Items    []json.RawMessage
+	NextPage string // token de la siguiente página; vacío si es la última
+	Raw      []byte // respuesta original, solo en fuentes remotas; se archiva
+}
+
+// StockSource entrega los items de una fuente página por página.
+// FetchPage recibe el token devuelto por la página anterior, o "" para la primera,
+// lo que permite retomar una sincronización desde su checkpoint.
+type StockSource interface {
+	FetchPage(ctx context.Context, token string) (Page, error)
+}
+
+// HTTPSource lee de la API externa configurada
+type HTTPSource struct {
+	baseURL string
+	client  *upstreamClient
+}
+
+// NewHTTPSource crea la fuente HTTP con la configuración de la API externa
+func NewHTTPSource(cfg config.Upstream) (*HTTPSource, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &HTTPSource{baseURL: cfg.URL, client: newUpstreamClient(cfg)}, nil
+}
+
+func (s *HTTPSource) FetchPage(ctx context.Context, token string) (Page, error) {
+	url := s.baseURL
+	if token != "" {
+		url = s.baseURL + "?next_page=" + token
+	}
+
+	apiResp, body, err := s.client.fetchPage(ctx, url)
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{Items: apiResp.Items, NextPage: apiResp.NextPage, Raw: body}, nil
+}
+
+// MemorySource pagina una lista de items en memoria. El token es el offset.
+type MemorySource struct {
+	items    []json.RawMessage
+	pageSize int
+}
+
+// NewFixtureSource crea una fuente en memoria con los items dados, útil en tests
+// y para sembrar bases de desarrollo
+func NewFixtureSource(pageSize int, items ...dto.StockItem) *MemorySource {
+	raw := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		b, _ := json.Marshal(item)
+		raw = append(raw, b)
+	}
+	return newMemorySource(raw, pageSize)
+}
+
+// NewFileSource carga un volcado local en formato JSON o NDJSON. Acepta un
+// array de items, una respuesta de la API ({"items": [...]}) o un item por línea.
+func NewFileSource(path string, pageSize int) (*MemorySource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+
+	items, err := parseDump(data)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+	return newMemorySource(items, pageSize), nil
+}
+
+func newMemorySource(items []json.RawMessage, pageSize int) *MemorySource {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return &MemorySource{items: items, pageSize: pageSize}
+}
+
+func (s *MemorySource) FetchPage(ctx context.Context, token string) (Page, error) {
+	offset := 0
+	if token != "" {
+		var err error
+		offset, err = strconv.Atoi(token)
+		if err != nil || offset < 0 || offset > len(s.items) {
+			return Page{}, fmt.Errorf("token de página inválido %q", token)
+		}
+	}
+
+	end := min(offset+s.pageSize, len(s.items))
+	page := Page{Items: s.items[offset:end]}
+	if end < len(s.items) {
+		page.NextPage = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+// parseDump interpreta el contenido de un volcado JSON o NDJSON
+func parseDump(data []byte) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("JSON inválido: %v", err)
+		}
+		return items, nil
+	case '{':
+		var resp APIResponse
+		if err := json.Unmarshal(trimmed, &resp); err == nil && resp.Items != nil {
+			return resp.Items, nil
+		}
+	}
+
+	// Un item por línea
+	var items []json.RawMessage
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if !json.Valid(text) {
+			return nil, fmt.Errorf("línea %d no es JSON válido", line)
+		}
+		items = append(items, json.RawMessage(bytes.Clone(text)))
+	}
+	return items, scanner.Err()
+}
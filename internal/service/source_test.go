@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySource_Paginates(t *testing.T) {
+	source := NewFixtureSource(2,
+		item("AAPL", "2025-05-03T00:00:00Z"),
+		item("GOOG", "2025-05-02T00:00:00Z"),
+		item("MSFT", "2025-05-01T00:00:00Z"),
+	)
+
+	first, err := source.FetchPage(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Len(t, first.Items, 2)
+	assert.Equal(t, "2", first.NextPage)
+
+	last, err := source.FetchPage(context.Background(), first.NextPage)
+	assert.NoError(t, err)
+	assert.Len(t, last.Items, 1)
+	assert.Empty(t, last.NextPage)
+
+	_, err = source.FetchPage(context.Background(), "abc")
+	assert.Error(t, err)
+}
+
+func TestNewFileSource_Formats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"array.json": `[{"ticker":"AAPL"},{"ticker":"GOOG"}]`,
+		"api.json":   `{"items":[{"ticker":"AAPL"},{"ticker":"GOOG"}],"next_page":""}`,
+		"items.ndjson": `{"ticker":"AAPL"}
+
+{"ticker":"GOOG"}
+`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		source, err := NewFileSource(path, 10)
+		assert.NoError(t, err, name)
+
+		page, err := source.FetchPage(context.Background(), "")
+		assert.NoError(t, err, name)
+		assert.Len(t, page.Items, 2, name)
+	}
+}
+
+func TestSyncStocks_FromFixtureSource(t *testing.T) {
+	db := setupTestDB(t)
+	source := NewFixtureSource(1,
+		item("AAPL", "2025-05-02T00:00:00Z"),
+		item("GOOG", "2025-05-01T00:00:00Z"),
+	)
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 2, result.Inserted)
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -2,80 +2,288 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"time"
 
 	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
 	"github.com/uptrace/bun"
 )
 
-
+// APIResponse es una página de la API externa. Los items se guardan crudos
+// para poder validarlos uno a uno y conservar el JSON de los rechazados.
 type APIResponse struct {
-	Items    []dto.StockItem `json:"items"`
-	NextPage string          `json:"next_page"`
+	Items    []json.RawMessage `json:"items"`
+	NextPage string            `json:"next_page"`
 }
 
-// FetchAndStoreStocks descarga los datos y los guarda en la base de datos
-func FetchAndStoreStocks(db *bun.DB) error {
+// DefaultResumeMaxAge es la antigüedad máxima de un checkpoint para retomarlo:
+// pasado ese tiempo la ejecución se da por abandonada y se empieza una nueva
+const DefaultResumeMaxAge = 24 * time.Hour
+
+// errNoSource se devuelve si se pide sincronizar sin indicar la fuente
+var errNoSource = errors.New("no se indicó la fuente de la sincronización")
+
+// SyncMode indica cómo se recorre la API externa
+type SyncMode int
+
+const (
+	// SyncFull descarga todas las páginas disponibles
+	SyncFull SyncMode = iota
+	// SyncIncremental se detiene al llegar a items ya almacenados
+	SyncIncremental
+)
+
+func (m SyncMode) String() string {
+	if m == SyncIncremental {
+		return "incremental"
+	}
+	return "full"
+}
 
-	apiURL := os.Getenv("API_URL")
-	authHeader := os.Getenv("AUTH_HEADER")
+// SyncOptions configura una sincronización
+type SyncOptions struct {
+	Mode SyncMode
+	// Resume retoma la última ejecución sin terminar, si existe, desde su checkpoint
+	Resume bool
+	// ResumeMaxAge descarta retomar una ejecución cuyo checkpoint no avanza
+	// hace más de este tiempo; por defecto DefaultResumeMaxAge
+	ResumeMaxAge time.Duration
+	// Trigger indica quién pidió la sincronización (TriggerStartup, TriggerSchedule, TriggerManual)
+	Trigger string
+	// RunID fija el ID de una ejecución nueva; si está vacío se genera uno
+	RunID string
+	// Source es de donde se leen los items, por ejemplo la API externa (NewHTTPSource)
+	Source StockSource
+	// Archive guarda las respuestas originales de la fuente; nil para no archivar
+	Archive *PageArchive
+	// Prefetch es cuántas páginas se descargan por adelantado; por defecto DefaultPrefetchPages
+	Prefetch int
+}
 
-	if apiURL == "" || authHeader == "" {
-	return fmt.Errorf("las variables de entorno API_URL o AUTH_HEADER no están definidas")
+// SyncResult resume lo que hizo una sincronización
+type SyncResult struct {
+	RunID    string
+	Pages    int
+	Inserted int
+	Updated  int // items existentes cuyos datos cambiaron
+	Skipped  int // items que ya existían según la clave natural
+	Rejected int // items que no pasaron la validación
 }
 
-	url := apiURL
-	page := 1
-	ctx := context.Background()
+// SyncStocks recorre la fuente página por página e inserta los items.
+// En modo incremental descarta los items anteriores al último almacenado y
+// deja de paginar en cuanto encuentra uno.
+// Las páginas se descargan por adelantado mientras se escriben las
+// anteriores; cada una se escribe junto con su checkpoint y el resultado
+// queda registrado en ingestion_runs.
+func SyncStocks(ctx context.Context, db *bun.DB, opts SyncOptions) (SyncResult, error) {
+	var result SyncResult
 
-	for {
-		fmt.Printf("📦 Descargando página %d...\n", page)
+	if opts.Source == nil {
+		return result, errNoSource
+	}
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return fmt.Errorf("error creando request: %v", err)
-		}
+	cp, err := startOrResume(ctx, db, opts)
+	if err != nil {
+		return result, err
+	}
+	result.RunID = cp.RunID
+
+	run, err := startRun(ctx, db, cp, opts.Trigger)
+	if err != nil {
+		return result, err
+	}
+
+	pipeline := newSyncPipeline(db, opts.Source, opts.Archive, opts.Prefetch)
+	err = pipeline.run(ctx, cp, &result)
 
-		req.Header.Set("Authorization", authHeader)
-		req.Header.Set("Content-Type", "application/json")
+	// Registrar el resultado aunque ctx se haya cancelado
+	if runErr := finishRun(context.WithoutCancel(ctx), db, run, cp, result, err); runErr != nil && err == nil {
+		err = runErr
+	}
+	if err != nil {
+		return result, err
+	}
 
-		resp, err := http.DefaultClient.Do(req)
+	fmt.Printf("✅ Sincronización %s completada: %d páginas, %d items nuevos, %d actualizados, %d ya existentes, %d rechazados.\n",
+		cp.Mode, result.Pages, result.Inserted, result.Updated, result.Skipped, result.Rejected)
+	return result, nil
+}
+
+// startOrResume devuelve el checkpoint desde el que continuar: el de la última
+// ejecución sin terminar si se pidió Resume y no está abandonada, o uno nuevo
+func startOrResume(ctx context.Context, db *bun.DB, opts SyncOptions) (*models.IngestionCheckpoint, error) {
+	if opts.Resume {
+		cp, err := LatestUnfinishedCheckpoint(ctx, db)
 		if err != nil {
-			return fmt.Errorf("error haciendo request: %v", err)
+			return nil, err
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("respuesta no exitosa: %d\n%s", resp.StatusCode, string(body))
+		maxAge := opts.ResumeMaxAge
+		if maxAge <= 0 {
+			maxAge = DefaultResumeMaxAge
 		}
-
-		var apiResp APIResponse
-		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-			return fmt.Errorf("error decodificando JSON: %v", err)
+		switch {
+		case cp == nil:
+		case time.Since(cp.UpdatedAt) > maxAge:
+			fmt.Printf("⏭️  La ejecución %s no avanza desde %s, se empieza una nueva.\n", cp.RunID, cp.UpdatedAt.Format(time.RFC3339))
+		default:
+			fmt.Printf("⏯️  Retomando ejecución %s desde la página %d...\n", cp.RunID, cp.Page+1)
+			return cp, nil
 		}
+	}
 
-		// Insertar todos los elementos de la página de una vez (más eficiente)
-		if len(apiResp.Items) > 0 {
-			_, err := db.NewInsert().Model(&apiResp.Items).Exec(ctx)
-			if err != nil {
-				return fmt.Errorf("error insertando en DB: %v", err)
-			}
+	var since time.Time
+	if opts.Mode == SyncIncremental {
+		var err error
+		since, err = LatestStockTime(ctx, db)
+		if err != nil {
+			return nil, err
 		}
+	}
+	return newCheckpoint(ctx, db, opts.RunID, opts.Mode, since)
+}
+
+// UpsertStocks inserta los items nuevos según la clave natural y actualiza la
+// compañía de los existentes si cambió, todo en una sola sentencia. Devuelve
+// cuántos se insertaron y cuántos se actualizaron.
+func UpsertStocks(ctx context.Context, db bun.IDB, items []models.StockItem) (inserted, updated int, err error) {
+	// Una sentencia no puede actualizar dos veces la misma fila: si el lote
+	// repite un item, gana el último
+	items = uniqueStocks(items)
+
+	existing, err := countExistingStocks(ctx, db, items)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	res, err := db.NewInsert().
+		Model(&items).
+		On("CONFLICT (" + models.StockItemNaturalKey + ") DO UPDATE").
+		Set("company = EXCLUDED.company").
+		Where("?TableAlias.company <> EXCLUDED.company").
+		Returning("NULL").
+		Exec(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error insertando en DB: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error leyendo filas insertadas: %v", err)
+	}
+	inserted = len(items) - existing
+	return inserted, int(n) - inserted, nil
+}
+
+// countExistingStocks cuenta cuántos items del lote ya están en stock_items
+func countExistingStocks(ctx context.Context, db bun.IDB, items []models.StockItem) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	keys := make([][]any, len(items))
+	for i, m := range items {
+		keys[i] = []any{m.Ticker, m.Brokerage, m.Time, m.Action, m.RatingFrom, m.RatingTo, m.TargetFrom, m.TargetTo}
+	}
+	n, err := db.NewSelect().
+		Model((*models.StockItem)(nil)).
+		Where("("+models.StockItemNaturalKey+") IN (?)", bun.In(keys)).
+		Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error consultando items existentes: %v", err)
+	}
+	return n, nil
+}
 
-		if apiResp.NextPage == "" {
-			break
+// uniqueStocks quita los items repetidos según la clave natural, conservando
+// el último de cada uno en la posición del primero
+func uniqueStocks(items []models.StockItem) []models.StockItem {
+	index := make(map[string]int, len(items))
+	result := make([]models.StockItem, 0, len(items))
+	for _, m := range items {
+		key := naturalKey(m)
+		if i, ok := index[key]; ok {
+			result[i] = m
+			continue
 		}
-		url = apiURL + "?next_page=" + apiResp.NextPage
-		page++
+		index[key] = len(result)
+		result = append(result, m)
+	}
+	return result
+}
+
+// LatestStockTime devuelve el time más reciente guardado en stock_items,
+// o el tiempo cero si la tabla está vacía
+func LatestStockTime(ctx context.Context, db *bun.DB) (time.Time, error) {
+	var latest models.StockItem
+	err := db.NewSelect().
+		Model(&latest).
+		Column("time").
+		Order("time DESC").
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error consultando el último time almacenado: %v", err)
 	}
+	return latest.Time, nil
+}
+
+// decodeItems valida los items crudos de una página. Los inválidos se devuelven
+// como rechazados junto con su JSON original y el motivo.
+func decodeItems(raw []json.RawMessage, runID string, page int) ([]models.StockItem, []models.StockItemRejected) {
+	valid := make([]models.StockItem, 0, len(raw))
+	var rejected []models.StockItemRejected
 
-	fmt.Println("✅ Datos descargados y almacenados con éxito.")
-	return nil
+	reject := func(r json.RawMessage, reason string) {
+		rejected = append(rejected, models.StockItemRejected{
+			RunID:     runID,
+			Page:      page,
+			Reason:    reason,
+			Raw:       r,
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+
+	for _, r := range raw {
+		var item dto.StockItem
+		if err := json.Unmarshal(r, &item); err != nil {
+			reject(r, fmt.Sprintf("JSON inválido: %v", err))
+			continue
+		}
+		m, err := item.ToModel()
+		if err != nil {
+			reject(r, err.Error())
+			continue
+		}
+		valid = append(valid, m)
+	}
+	return valid, rejected
 }
 
+// newerThan descarta los items anteriores a latest. Los de la misma fecha se
+// conservan porque pueden ser ratings distintos publicados en el mismo
+// instante; si ya estaban guardados, el upsert por clave natural los omite.
+// El segundo valor indica si se descartó alguno.
+func newerThan(items []models.StockItem, latest time.Time) ([]models.StockItem, bool) {
+	if latest.IsZero() {
+		return items, false
+	}
 
+	result := make([]models.StockItem, 0, len(items))
+	reachedKnown := false
+	for _, m := range items {
+		if m.Time.Before(latest) {
+			reachedKnown = true
+			continue
+		}
+		result = append(result, m)
+	}
+	return result, reachedKnown
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/dto"
+	"github.com/Carlosmercg/stock-analyzer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func setupTestDB(t *testing.T) *bun.DB {
+	sqliteDB, err := sql.Open(sqliteshim.ShimName, ":memory:")
+	assert.NoError(t, err)
+	sqliteDB.SetMaxOpenConns(1)
+
+	db := bun.NewDB(sqliteDB, sqlitedialect.New())
+	err = db.ResetModel(context.Background(), (*models.StockItem)(nil), (*models.IngestionCheckpoint)(nil), (*models.IngestionRun)(nil), (*models.StockItemRejected)(nil),
+		(*models.ScoringProfile)(nil), (*models.ScoringProfileVersion)(nil))
+	assert.NoError(t, err)
+
+	return db
+}
+
+// fakeAPI sirve las páginas indicadas siguiendo el parámetro next_page y
+// devuelve una fuente HTTP que apunta a ella
+func fakeAPI(t *testing.T, pages map[string]APIResponse) *HTTPSource {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("next_page")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(server.Close)
+
+	source, err := NewHTTPSource(config.Upstream{
+		URL:         server.URL,
+		AuthHeader:  "Bearer test",
+		Timeout:     time.Second,
+		MaxAttempts: 1,
+	})
+	assert.NoError(t, err)
+	return source
+}
+
+func item(ticker, time string) dto.StockItem {
+	return dto.StockItem{
+		Ticker:     ticker,
+		TargetFrom: "$10.00",
+		TargetTo:   "$12.00",
+		Company:    ticker + " Inc.",
+		Action:     "target raised by",
+		Brokerage:  "Goldman",
+		RatingFrom: "Buy",
+		RatingTo:   "Buy",
+		Time:       time,
+	}
+}
+
+// page arma una página de la API con los items serializados
+func page(next string, items ...dto.StockItem) APIResponse {
+	resp := APIResponse{NextPage: next}
+	for _, it := range items {
+		raw, _ := json.Marshal(it)
+		resp.Items = append(resp.Items, raw)
+	}
+	return resp
+}
+
+func model(t *testing.T, it dto.StockItem) models.StockItem {
+	m, err := it.ToModel()
+	assert.NoError(t, err)
+	return m
+}
+
+func TestSyncStocks_Full(t *testing.T) {
+	db := setupTestDB(t)
+	source := fakeAPI(t, map[string]APIResponse{
+		"":   page("p2", item("AAPL", "2025-05-02T00:00:00Z")),
+		"p2": page("", item("GOOG", "2025-05-01T00:00:00Z")),
+	})
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 2, result.Inserted)
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestSyncStocks_IncrementalStopsAtKnownItems(t *testing.T) {
+	db := setupTestDB(t)
+	existing := model(t, item("GOOG", "2025-05-01T00:00:00Z"))
+	_, err := db.NewInsert().Model(&existing).Exec(context.Background())
+	assert.NoError(t, err)
+
+	source := fakeAPI(t, map[string]APIResponse{
+		"": page("p2", item("AAPL", "2025-05-02T00:00:00Z"), item("GOOG", "2025-05-01T00:00:00Z"), item("MSFT", "2025-04-30T00:00:00Z")),
+		// Si el loader siguiera paginando fallaría con 404
+	})
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncIncremental, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Pages)
+	assert.Equal(t, 1, result.Inserted)
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestSyncStocks_IncrementalKeepsItemsWithLatestTime(t *testing.T) {
+	db := setupTestDB(t)
+	existing := model(t, item("GOOG", "2025-05-01T00:00:00Z"))
+	_, err := db.NewInsert().Model(&existing).Exec(context.Background())
+	assert.NoError(t, err)
+
+	// MSFT se publicó en el mismo instante que el último item guardado
+	source := fakeAPI(t, map[string]APIResponse{
+		"":   page("p2", item("AAPL", "2025-05-02T00:00:00Z"), item("MSFT", "2025-05-01T00:00:00Z")),
+		"p2": page("p3", item("GOOG", "2025-05-01T00:00:00Z"), item("TSLA", "2025-04-30T00:00:00Z")),
+	})
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncIncremental, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 1, result.Skipped)
+
+	var tickers []string
+	err = db.NewSelect().Model((*models.StockItem)(nil)).Column("ticker").Order("ticker").Scan(context.Background(), &tickers)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "GOOG", "MSFT"}, tickers)
+}
+
+func TestSyncStocks_FullResyncDoesNotDuplicate(t *testing.T) {
+	db := setupTestDB(t)
+	source := fakeAPI(t, map[string]APIResponse{
+		"": page("", item("AAPL", "2025-05-02T00:00:00Z"), item("GOOG", "2025-05-01T00:00:00Z")),
+	})
+
+	existing := model(t, item("GOOG", "2025-05-01T00:00:00Z"))
+	_, err := db.NewInsert().Model(&existing).Exec(context.Background())
+	assert.NoError(t, err)
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 1, result.Skipped)
+
+	result, err = SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Inserted)
+	assert.Equal(t, 2, result.Skipped)
+
+	count, err := db.NewSelect().Model((*models.StockItem)(nil)).Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestSyncStocks_ResumesFromCheckpoint(t *testing.T) {
+	db := setupTestDB(t)
+	pages := map[string]APIResponse{
+		"": page("p2", item("AAPL", "2025-05-02T00:00:00Z")),
+		// p2 todavía no existe: la primera ejecución falla con 404 tras escribir la página 1
+	}
+	source := fakeAPI(t, pages)
+
+	_, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.Error(t, err)
+
+	cp, err := LatestUnfinishedCheckpoint(context.Background(), db)
+	assert.NoError(t, err)
+	if assert.NotNil(t, cp) {
+		assert.Equal(t, 1, cp.Page)
+		assert.Equal(t, "p2", cp.NextPage)
+		assert.Equal(t, 1, cp.RowsWritten)
+	}
+
+	pages["p2"] = page("", item("GOOG", "2025-05-01T00:00:00Z"))
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncIncremental, Resume: true, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, cp.RunID, result.RunID)
+	assert.Equal(t, 1, result.Pages)
+	assert.Equal(t, 1, result.Inserted)
+
+	cp, err = LatestUnfinishedCheckpoint(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+}
+
+func TestSyncStocks_DoesNotResumeAbandonedRuns(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	abandoned, err := newCheckpoint(ctx, db, "", SyncFull, time.Time{})
+	assert.NoError(t, err)
+	abandoned.Page, abandoned.NextPage = 1, "p2"
+	assert.NoError(t, saveCheckpoint(ctx, db, abandoned))
+	_, err = db.NewUpdate().Model(abandoned).Set("updated_at = ?", time.Now().Add(-2*DefaultResumeMaxAge)).WherePK().Exec(ctx)
+	assert.NoError(t, err)
+
+	source := fakeAPI(t, map[string]APIResponse{"": page("", item("AAPL", "2025-05-02T00:00:00Z"))})
+	result, err := SyncStocks(ctx, db, SyncOptions{Mode: SyncFull, Resume: true, Source: source})
+	assert.NoError(t, err)
+	assert.NotEqual(t, abandoned.RunID, result.RunID)
+	assert.Equal(t, 1, result.Inserted)
+
+	// Con una antigüedad máxima mayor sí se habría retomado
+	cp, err := startOrResume(ctx, db, SyncOptions{Resume: true, ResumeMaxAge: 3 * DefaultResumeMaxAge})
+	assert.NoError(t, err)
+	assert.Equal(t, abandoned.RunID, cp.RunID)
+}
+
+func TestSyncStocks_QuarantinesInvalidItems(t *testing.T) {
+	db := setupTestDB(t)
+
+	badTime := item("MSFT", "ayer")
+	badTarget := item("TSLA", "2025-05-01T00:00:00Z")
+	badTarget.TargetTo = "N/A"
+	unknownRating := item("NVDA", "2025-05-01T00:00:00Z")
+	unknownRating.RatingTo = "Maybe"
+	unknownAction := item("INTC", "2025-05-01T00:00:00Z")
+	unknownAction.Action = "coverage transferred by"
+	// Los ratings del vocabulario se aceptan aunque sean poco habituales
+	sectorWeight := item("AMD", "2025-05-01T00:00:00Z")
+	sectorWeight.RatingTo = "Sector Weight"
+
+	resp := page("", item("AAPL", "2025-05-02T00:00:00Z"), badTime, badTarget, unknownRating, unknownAction, sectorWeight)
+	resp.Items = append(resp.Items, []byte(`"no es un objeto"`))
+	source := fakeAPI(t, map[string]APIResponse{"": resp})
+
+	result, err := SyncStocks(context.Background(), db, SyncOptions{Mode: SyncFull, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 5, result.Rejected)
+
+	rejected, total, err := ListRejectedItems(context.Background(), db, result.RunID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Contains(t, rejected[0].Reason, "time inválido")
+	assert.Contains(t, string(rejected[0].Raw), "MSFT")
+	// El JSON original se devuelve como objeto, no como texto escapado
+	out, err := json.Marshal(rejected[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"raw":{"ticker":"MSFT"`)
+	assert.Contains(t, rejected[1].Reason, "target_to")
+	assert.Contains(t, rejected[2].Reason, "rating_to desconocido")
+	assert.Contains(t, rejected[3].Reason, "action desconocida")
+	assert.Contains(t, rejected[4].Reason, "JSON inválido")
+
+	run, _, err := GetIngestionRun(context.Background(), db, result.RunID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, run.Rejected)
+}
+
+func TestUpsertStocks_UpdatesChangedCompany(t *testing.T) {
+	db := setupTestDB(t)
+	original := model(t, item("AAPL", "2025-05-02T00:00:00Z"))
+	inserted, updated, err := UpsertStocks(context.Background(), db, []models.StockItem{original})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, inserted)
+	assert.Equal(t, 0, updated)
+
+	renamed := original
+	renamed.ID = 0
+	renamed.Company = "Apple Inc."
+	inserted, updated, err = UpsertStocks(context.Background(), db, []models.StockItem{renamed})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, inserted)
+	assert.Equal(t, 1, updated)
+
+	// Un lote que repite el item y agrega otro: gana el último y el resto no cambia
+	other := model(t, item("GOOG", "2025-05-02T00:00:00Z"))
+	inserted, updated, err = UpsertStocks(context.Background(), db, []models.StockItem{renamed, other, original})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, inserted)
+	assert.Equal(t, 1, updated)
+
+	var stored models.StockItem
+	assert.NoError(t, db.NewSelect().Model(&stored).Where("ticker = ?", "AAPL").Scan(context.Background()))
+	assert.Equal(t, "AAPL Inc.", stored.Company)
+}
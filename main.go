@@ -1,46 +1,98 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
 	"github.com/Carlosmercg/stock-analyzer/internal/database"
-	"github.com/Carlosmercg/stock-analyzer/internal/router"
-	"github.com/Carlosmercg/stock-analyzer/internal/service"
-	"github.com/joho/godotenv"
+	"github.com/uptrace/bun"
 )
 
+// command es un subcomando de la CLI
+type command struct {
+	name    string
+	usage   string
+	migrate bool // aplicar las migraciones pendientes antes de ejecutarlo
+	run     func(cfg *config.Config, db *bun.DB, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve [-port 8080] [-sync]", true, runServeCommand},
+	{"migrate", "migrate up|down|status|unlock", false, runMigrateCommand},
+	{"sync", "sync [-full|-incremental] [-dry-run [-diff diff.ndjson]] [-file volcado.ndjson]", true, runSyncCommand},
+	{"replay", "replay [-dir archivo] <run_id>", true, runReplayCommand},
+	{"score", "score [-strategy default|-profile P] [-top 20] [-brokerage X] [-as-of fecha] [-explain]", false, runScoreCommand},
+	{"export", "export [-format csv|json] [-o archivo]", false, runExportCommand},
+}
+
 func main() {
-	// 1. Conectar a Cockroach y guardar instancia
-	db := database.InitCockroach()
+	// Sin subcomando, o solo con flags, se levanta el servidor
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage()
+		return
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		printUsage()
+		log.Fatalf("❌ Subcomando desconocido %q", name)
+	}
 
-	// 2. Cargar variables de entorno
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("❌ Error cargando .env: %v", err)
+	// 1. Cargar y validar la configuración
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
+	log.Printf("⚙️  Configuración: %s", cfg)
 
-	// 3. Crear tabla y cargar datos si no existe
-	if !database.TableExists(db, "stock_items") {
-		log.Println("🆕 Tabla no existe, creando tabla y cargando datos iniciales...")
-		database.Migrate(db)
+	// 2. Conectar a la base de datos configurada y guardar instancia
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 
-		if err := service.FetchAndStoreStocks(db); err != nil {
-			log.Fatalf("❌ Error descargando y guardando datos: %v", err)
+	// 3. Aplicar migraciones
+	if cmd.migrate {
+		if err := database.MigrateUp(context.Background(), db); err != nil {
+			log.Fatalf("❌ %v", err)
 		}
-	} else {
-		log.Println("ℹ️  Tabla stock_items ya existe, no se realiza migración ni carga.")
 	}
 
-	// 4. Configurar servidor
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// 4. Ejecutar el subcomando y cerrar el pool de conexiones
+	err = cmd.run(cfg, db, args)
+	if cerr := db.Close(); cerr != nil {
+		log.Printf("⚠️  Error cerrando la base de datos: %v", cerr)
 	}
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		log.Fatalf("❌ %v", err)
+	}
+}
 
-	r := router.SetupRouter(db)
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
 
-	log.Printf("🚀 Servidor escuchando en http://localhost:%s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("❌ Error al iniciar el servidor: %v", err)
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Uso: stock-analyzer <subcomando> [flags]")
+	fmt.Fprintln(os.Stderr)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
 	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Sin subcomando se ejecuta serve.")
 }
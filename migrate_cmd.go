@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/database"
+	"github.com/uptrace/bun"
+)
+
+// runMigrateCommand ejecuta migrate up|down|status|unlock
+func runMigrateCommand(cfg *config.Config, db *bun.DB, args []string) error {
+	ctx := context.Background()
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		return database.MigrateUp(ctx, db)
+	case "down":
+		return database.MigrateDown(ctx, db)
+	case "unlock":
+		return database.UnlockMigrations(ctx, db)
+	case "status":
+		ms, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, m := range ms {
+			if m.IsApplied() {
+				fmt.Printf("✅ %s (grupo %d, %s)\n", m, m.GroupID, m.MigratedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("⏳ %s (pendiente)\n", m)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("acción de migrate desconocida %q: usa up, down, status o unlock", action)
+	}
+}
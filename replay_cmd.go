@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/uptrace/bun"
+)
+
+// runReplayCommand vuelve a cargar las páginas archivadas de una ejecución
+// sin llamar a la API externa
+func runReplayCommand(cfg *config.Config, db *bun.DB, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	dir := fs.String("dir", cfg.Ingestion.ArchiveDir, "directorio del archivo; por defecto ARCHIVE_DIR")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("uso: replay [-dir archivo] <run_id>")
+	}
+
+	archive := service.NewPageArchive(*dir)
+	if archive == nil {
+		return fmt.Errorf("el archivo de páginas está desactivado (ARCHIVE_DIR=off)")
+	}
+
+	source, err := service.NewArchiveSource(archive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	opts := service.SyncOptions{Mode: service.SyncFull, Trigger: service.TriggerReplay, Source: source}
+	_, err = service.SyncStocks(context.Background(), db, opts)
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/scoring"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/uptrace/bun"
+)
+
+// runScoreCommand imprime en stdout el ranking de ratings según la estrategia
+func runScoreCommand(cfg *config.Config, db *bun.DB, args []string) error {
+	fs := flag.NewFlagSet("score", flag.ContinueOnError)
+	strategy := fs.String("strategy", scoring.Default, "estrategia de puntuación: "+strings.Join(scoring.Names(), ", "))
+	top := fs.Int("top", 20, "cantidad de ratings a mostrar")
+	profile := fs.String("profile", "", "perfil de pesos guardado, en lugar de -strategy")
+	brokerage := fs.String("brokerage", "", "solo ratings de esta corredora")
+	explain := fs.Bool("explain", false, "mostrar el desglose de cada score")
+	asOfFlag := fs.String("as-of", "", "ranking a esa fecha (AAAA-MM-DD), solo con los ratings publicados hasta entonces")
+	halfLife := fs.Duration("half-life", cfg.Scoring.HalfLife, "vida media del decaimiento por antigüedad (0 sin decaimiento)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *profile != "" && flagSet(fs, "strategy") {
+		return errors.New("usa -strategy o -profile, no ambos")
+	}
+
+	ctx := context.Background()
+	var scorer scoring.Scorer
+	var err error
+	if *profile != "" {
+		scorer, err = service.ProfileScorer(ctx, db, *profile)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("perfil de scoring desconocido %q", *profile)
+		}
+	} else {
+		scorer, err = scoring.Get(*strategy)
+	}
+	if err != nil {
+		return err
+	}
+
+	var asOf time.Time
+	reference := time.Now()
+	if *asOfFlag != "" {
+		if asOf, err = scoring.ParseAsOf(*asOfFlag); err != nil {
+			return err
+		}
+		reference = asOf
+	}
+	scorer = scoring.WithDecay(scorer, *halfLife, reference)
+
+	stocks, err := service.ListStocks(ctx, db, *brokerage, asOf)
+	if err != nil {
+		return err
+	}
+	scored := scoring.Rank(stocks, scorer, *top)
+	if *explain {
+		scoring.Explain(scored, scorer)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTICKER\tEMPRESA\tCORREDORA\tRATING\tOBJETIVO\tSCORE\t")
+	for i, s := range scored {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s → %s\t%.2f\t\n",
+			i+1, s.Ticker, s.Company, s.Brokerage, s.RatingTo, s.TargetFrom, s.TargetTo, s.Score)
+		for _, c := range s.Explanation {
+			fmt.Fprintf(w, "\t  %s\t%s\t\t\t\t%+.2f\t\n", c.Name, c.Rule, c.Value)
+		}
+	}
+	return w.Flush()
+}
+
+// flagSet indica si el flag se pasó en la línea de comandos
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
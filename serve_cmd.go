@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/router"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/uptrace/bun"
+)
+
+// runServeCommand levanta el servidor HTTP. Los datos se cargan con el
+// subcomando sync; con -sync además corren en segundo plano la carga inicial
+// y el refresco periódico.
+func runServeCommand(cfg *config.Config, db *bun.DB, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	port := fs.String("port", cfg.Server.Port, "puerto HTTP (por defecto PORT o 8080)")
+	withSync := fs.Bool("sync", false, "cargar datos al iniciar y refrescarlos cada REFRESH_INTERVAL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Sin -sync la fuente solo hace falta para las ejecuciones que se piden
+	// desde /api/admin/ingestions, así que sin API externa se sirve igual
+	var source service.StockSource
+	httpSource, err := service.NewHTTPSource(cfg.Upstream)
+	switch {
+	case err == nil:
+		source = httpSource
+	case *withSync:
+		return err
+	default:
+		log.Printf("⚠️  %v: no se podrán pedir sincronizaciones desde la API de administración", err)
+	}
+	interval := time.Duration(0)
+	if *withSync {
+		interval = cfg.Ingestion.RefreshInterval
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Con -sync la carga inicial corre en segundo plano: el servidor responde
+	// desde ya y los endpoints de datos devuelven 503 hasta que haya datos
+	readiness := service.NewReadiness()
+	hasData, err := service.HasStocks(ctx, db)
+	if err != nil {
+		return err
+	}
+	opts := service.SyncOptions{Mode: service.SyncFull, Resume: true, Trigger: service.TriggerStartup}
+	if hasData || !*withSync {
+		readiness.MarkReady()
+		opts.Mode = service.SyncIncremental
+	}
+
+	job := service.NewRefreshJob(db, interval, source, service.NewPageArchive(cfg.Ingestion.ArchiveDir))
+	jobDone := make(chan struct{})
+	go func() {
+		defer close(jobDone)
+
+		if *withSync {
+			if hasData {
+				log.Println("🔄 Tabla stock_items con datos, sincronizando datos nuevos...")
+			} else {
+				log.Println("🆕 Tabla stock_items vacía, cargando datos iniciales...")
+			}
+			if _, err := job.Bootstrap(ctx, opts, readiness); err != nil {
+				log.Printf("⚠️  Error en la carga inicial: %v", err)
+			}
+		}
+		job.Start(ctx)
+	}()
+
+	srv := &http.Server{
+		Addr:              ":" + *port,
+		Handler:           router.SetupRouter(cfg, db, job, readiness),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Servidor escuchando en http://localhost:%s", *port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		stop()
+		<-jobDone
+		return fmt.Errorf("error al iniciar el servidor: %v", err)
+	case <-ctx.Done():
+	}
+	// Una segunda señal mata el proceso sin esperar
+	stop()
+
+	return shutdown(srv, jobDone, cfg.Server.ShutdownTimeout)
+}
+
+// shutdown deja de aceptar conexiones y espera, como mucho timeout, a que
+// terminen las peticiones en curso y el refresco en segundo plano, que ya
+// recibió la cancelación por el contexto
+func shutdown(srv *http.Server, jobDone <-chan struct{}, timeout time.Duration) error {
+	log.Printf("🛑 Apagando: drenando peticiones en curso (máximo %s)...", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		srv.Close()
+		errs = append(errs, fmt.Errorf("peticiones sin terminar al apagar: %v", err))
+	}
+
+	select {
+	case <-jobDone:
+	case <-ctx.Done():
+		errs = append(errs, errors.New("la sincronización en segundo plano no se detuvo a tiempo"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	log.Println("👋 Servidor detenido.")
+	return nil
+}
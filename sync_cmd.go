@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Carlosmercg/stock-analyzer/internal/config"
+	"github.com/Carlosmercg/stock-analyzer/internal/service"
+	"github.com/uptrace/bun"
+)
+
+// runSyncCommand ejecuta una sincronización sin levantar el servidor.
+// Con -file lee un volcado local JSON/NDJSON en vez de la API externa, y con
+// -dry-run solo informa qué cambiaría.
+func runSyncCommand(cfg *config.Config, db *bun.DB, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	full := fs.Bool("full", false, "recorrer todas las páginas en vez de solo las nuevas")
+	incremental := fs.Bool("incremental", false, "solo los items desde el último almacenado (por defecto)")
+	file := fs.String("file", "", "volcado JSON/NDJSON a cargar en vez de la API externa")
+	pageSize := fs.Int("page-size", service.DefaultPageSize, "items por página al leer -file")
+	prefetch := fs.Int("prefetch", service.DefaultPrefetchPages, "páginas a descargar por adelantado")
+	dryRun := fs.Bool("dry-run", false, "mostrar qué cambiaría sin escribir en la base de datos")
+	diffPath := fs.String("diff", "", "con -dry-run, archivo NDJSON donde escribir el detalle")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *full && *incremental {
+		return fmt.Errorf("-full e -incremental son excluyentes")
+	}
+	if *diffPath != "" && !*dryRun {
+		return fmt.Errorf("-diff solo se puede usar con -dry-run")
+	}
+
+	opts := service.SyncOptions{
+		Mode:     service.SyncIncremental,
+		Trigger:  service.TriggerManual,
+		Prefetch: *prefetch,
+	}
+	if *full {
+		opts.Mode = service.SyncFull
+	}
+	if *file != "" {
+		source, err := service.NewFileSource(*file, *pageSize)
+		if err != nil {
+			return err
+		}
+		opts.Source = source
+	} else {
+		source, err := service.NewHTTPSource(cfg.Upstream)
+		if err != nil {
+			return err
+		}
+		opts.Source = source
+		opts.Archive = service.NewPageArchive(cfg.Ingestion.ArchiveDir)
+	}
+
+	if *dryRun {
+		return runDryRun(db, opts, *diffPath)
+	}
+
+	_, err := service.SyncStocks(context.Background(), db, opts)
+	return err
+}
+
+func runDryRun(db *bun.DB, opts service.SyncOptions, diffPath string) error {
+	var diff io.Writer
+	if diffPath != "" {
+		f, err := os.Create(diffPath)
+		if err != nil {
+			return fmt.Errorf("error creando %s: %v", diffPath, err)
+		}
+		defer f.Close()
+		diff = f
+	}
+
+	_, err := service.DryRunStocks(context.Background(), db, opts, diff)
+	return err
+}